@@ -1,9 +1,18 @@
 // Package bitmap creates bitmap images.
 package bitmap
 
-import "math"
+import (
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+
+	"github.com/bit101/bitlib/blcolor"
+)
 
 // Bitmap represents a bitmap image.
+// Pixels holds interleaved r, g, b, a values from 0.0 to 1.0, four per pixel, rows from top to bottom.
+// Alpha is stored unpremultiplied, the same as blcolor.Color.
 type Bitmap struct {
 	Width, Height int
 	Pixels        []float64
@@ -13,39 +22,72 @@ type Bitmap struct {
 func NewBitmap(w, h int) *Bitmap {
 	c := &Bitmap{
 		w, h,
-		make([]float64, w*h*3),
+		make([]float64, w*h*4),
 	}
 	c.Clear(0, 0, 0)
 	return c
 }
 
-// Clear clears the bitmap.
+// Clear clears the bitmap to an opaque color.
 func (c *Bitmap) Clear(r, g, b float64) {
-	for i := 0; i < len(c.Pixels); i += 3 {
-		c.Pixels[i] = b
+	c.ClearRGBA(r, g, b, 1)
+}
+
+// ClearRGBA clears the bitmap to the given rgba values.
+func (c *Bitmap) ClearRGBA(r, g, b, a float64) {
+	for i := 0; i < len(c.Pixels); i += 4 {
+		c.Pixels[i] = r
 		c.Pixels[i+1] = g
-		c.Pixels[i+2] = r
+		c.Pixels[i+2] = b
+		c.Pixels[i+3] = a
 	}
 }
 
+// ClearColor clears the bitmap to the given color.
+func (c *Bitmap) ClearColor(color blcolor.Color) {
+	c.ClearRGBA(color.R, color.G, color.B, color.A)
+}
+
 // GetPixel returns the rgb values of the pixel at the given coords.
 func (c *Bitmap) GetPixel(x, y int) (float64, float64, float64) {
+	r, g, b, _ := c.GetPixelRGBA(x, y)
+	return r, g, b
+}
+
+// GetPixelRGBA returns the rgba values of the pixel at the given coords.
+func (c *Bitmap) GetPixelRGBA(x, y int) (float64, float64, float64, float64) {
 	if x >= c.Width || x < 0 || y >= c.Height || y < 0 {
-		return 0, 0, 0
+		return 0, 0, 0, 0
 	}
-	index := (y*c.Width + x) * 3
-	return c.Pixels[index+2], c.Pixels[index+1], c.Pixels[index]
+	index := (y*c.Width + x) * 4
+	return c.Pixels[index], c.Pixels[index+1], c.Pixels[index+2], c.Pixels[index+3]
+}
+
+// GetPixelColor returns the color of the pixel at the given coords.
+func (c *Bitmap) GetPixelColor(x, y int) blcolor.Color {
+	return blcolor.RGBA(c.GetPixelRGBA(x, y))
 }
 
-// SetPixel sets the rgb values of the pixel at the given coords.
+// SetPixel sets the rgb values of the pixel at the given coords. The pixel will be opaque.
 func (c *Bitmap) SetPixel(x, y int, r, g, b float64) {
+	c.SetPixelRGBA(x, y, r, g, b, 1)
+}
+
+// SetPixelRGBA sets the rgba values of the pixel at the given coords, replacing what is there.
+func (c *Bitmap) SetPixelRGBA(x, y int, r, g, b, a float64) {
 	if x >= c.Width || x < 0 || y >= c.Height || y < 0 {
 		return
 	}
-	index := (y*c.Width + x) * 3
-	c.Pixels[index] = clamp(b)
+	index := (y*c.Width + x) * 4
+	c.Pixels[index] = clamp(r)
 	c.Pixels[index+1] = clamp(g)
-	c.Pixels[index+2] = clamp(r)
+	c.Pixels[index+2] = clamp(b)
+	c.Pixels[index+3] = clamp(a)
+}
+
+// SetPixelColor sets the pixel at the given coords to the given color, replacing what is there.
+func (c *Bitmap) SetPixelColor(x, y int, color blcolor.Color) {
+	c.SetPixelRGBA(x, y, color.R, color.G, color.B, color.A)
 }
 
 // SetPixelGray sets the the pixel at the given coords to the gray value given.
@@ -53,9 +95,81 @@ func (c *Bitmap) SetPixelGray(x, y int, val float64) {
 	c.SetPixel(x, y, val, val, val)
 }
 
+// BlendPixel composites the given rgba values over the existing pixel at the given coords.
+func (c *Bitmap) BlendPixel(x, y int, r, g, b, a float64) {
+	if x >= c.Width || x < 0 || y >= c.Height || y < 0 {
+		return
+	}
+	a = clamp(a)
+	if a == 0 {
+		return
+	}
+	index := (y*c.Width + x) * 4
+	if a == 1 {
+		c.Pixels[index] = clamp(r)
+		c.Pixels[index+1] = clamp(g)
+		c.Pixels[index+2] = clamp(b)
+		c.Pixels[index+3] = 1
+		return
+	}
+	// source over: out = src * srcA + dst * dstA * (1 - srcA), unpremultiplied by outA.
+	dstA := c.Pixels[index+3] * (1 - a)
+	outA := a + dstA
+	c.Pixels[index] = (clamp(r)*a + c.Pixels[index]*dstA) / outA
+	c.Pixels[index+1] = (clamp(g)*a + c.Pixels[index+1]*dstA) / outA
+	c.Pixels[index+2] = (clamp(b)*a + c.Pixels[index+2]*dstA) / outA
+	c.Pixels[index+3] = outA
+}
+
+// BlendPixelColor composites the given color over the existing pixel at the given coords.
+func (c *Bitmap) BlendPixelColor(x, y int, color blcolor.Color) {
+	c.BlendPixel(x, y, color.R, color.G, color.B, color.A)
+}
+
+// Composite draws another bitmap over this one at the given location, blending with its alpha.
+// opacity scales the alpha of the source bitmap, from 0.0 to 1.0.
+func (c *Bitmap) Composite(src *Bitmap, x, y int, opacity float64) {
+	for j := 0; j < src.Height; j++ {
+		for i := 0; i < src.Width; i++ {
+			r, g, b, a := src.GetPixelRGBA(i, j)
+			c.BlendPixel(x+i, y+j, r, g, b, a*opacity)
+		}
+	}
+}
+
+// Encode writes the bitmap to a writer in the given format.
+func (c *Bitmap) Encode(w io.Writer, format Format) error {
+	switch format {
+	case FormatBmp:
+		return WriteBmp(w, c.Pixels, c.Width, -c.Height)
+	case FormatPng:
+		return WritePng(w, c.Pixels, c.Width, c.Height)
+	case FormatPpm:
+		return WritePpm(w, c.Pixels, c.Width, c.Height)
+	case FormatPgm:
+		return WritePgm(w, c.Pixels, c.Width, c.Height)
+	case FormatTga:
+		return WriteTga(w, c.Pixels, c.Width, c.Height)
+	}
+	return fmt.Errorf("bitmap: unsupported format %d", format)
+}
+
 // SaveImage saves the bitmap to a file.
-func (c *Bitmap) SaveImage(filename string) {
-	EncodeBmp(c.Pixels, c.Width, -c.Height, filename)
+// The format is chosen from the file extension, and an error is returned if it is not recognized.
+// Use SaveImageAs to save in a format that doesn't match the extension.
+func (c *Bitmap) SaveImage(filename string) error {
+	format, ok := FormatFromFilename(filename)
+	if !ok {
+		return fmt.Errorf("bitmap: unsupported file extension %q", filepath.Ext(filename))
+	}
+	return c.SaveImageAs(filename, format)
+}
+
+// SaveImageAs saves the bitmap to a file in the given format, regardless of the file extension.
+func (c *Bitmap) SaveImageAs(filename string, format Format) error {
+	return encodeFile(filename, func(w io.Writer) error {
+		return c.Encode(w, format)
+	})
 }
 
 // clamp clamps a channel value between 0 and 1.
@@ -0,0 +1,91 @@
+// Package bitmap creates bitmap images.
+package bitmap
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/bitlib/blmath"
+)
+
+func TestPixelColor(t *testing.T) {
+	bmp := NewBitmap(4, 4)
+	c := blcolor.RGBA(0.2, 0.4, 0.6, 0.8)
+	bmp.SetPixelColor(1, 2, c)
+	result := bmp.GetPixelColor(1, 2)
+	if !result.Equals(c) {
+		t.Errorf("Expected %v, got %v\n", c, result)
+	}
+
+	// out of bounds
+	result = bmp.GetPixelColor(4, 2)
+	if !result.Equals(blcolor.RGBA(0, 0, 0, 0)) {
+		t.Errorf("Expected transparent, got %v\n", result)
+	}
+
+	// new bitmaps are opaque black
+	result = bmp.GetPixelColor(0, 0)
+	if !result.Equals(blcolor.RGBA(0, 0, 0, 1)) {
+		t.Errorf("Expected opaque black, got %v\n", result)
+	}
+}
+
+func TestBlendPixel(t *testing.T) {
+	type test struct {
+		dst, src, expected blcolor.Color
+	}
+	tests := []test{
+		// opaque source replaces
+		{blcolor.RGBA(1, 0, 0, 1), blcolor.RGBA(0, 0, 1, 1), blcolor.RGBA(0, 0, 1, 1)},
+		// transparent source does nothing
+		{blcolor.RGBA(1, 0, 0, 1), blcolor.RGBA(0, 0, 1, 0), blcolor.RGBA(1, 0, 0, 1)},
+		// half over opaque
+		{blcolor.RGBA(1, 0, 0, 1), blcolor.RGBA(0, 0, 1, 0.5), blcolor.RGBA(0.5, 0, 0.5, 1)},
+		// half over transparent keeps source color
+		{blcolor.RGBA(0, 0, 0, 0), blcolor.RGBA(0, 1, 0, 0.5), blcolor.RGBA(0, 1, 0, 0.5)},
+		// half over half
+		{blcolor.RGBA(1, 0, 0, 0.5), blcolor.RGBA(0, 0, 1, 0.5), blcolor.RGBA(1.0/3, 0, 2.0/3, 0.75)},
+	}
+	bmp := NewBitmap(1, 1)
+	for _, tc := range tests {
+		bmp.SetPixelColor(0, 0, tc.dst)
+		bmp.BlendPixelColor(0, 0, tc.src)
+		result := bmp.GetPixelColor(0, 0)
+		if !result.Equals(tc.expected) {
+			t.Errorf("Expected %v, got %v\n", tc.expected, result)
+		}
+	}
+}
+
+func TestComposite(t *testing.T) {
+	dst := NewBitmap(4, 4)
+	dst.Clear(1, 1, 1)
+	src := NewBitmap(2, 2)
+	src.ClearRGBA(0, 0, 0, 1)
+	dst.Composite(src, 3, 3, 0.5)
+
+	r, g, b := dst.GetPixel(3, 3)
+	if !blmath.Equalish(r, 0.5, 0.00001) || g != r || b != r {
+		t.Errorf("Expected 0.5 gray, got %f, %f, %f\n", r, g, b)
+	}
+	r, _, _ = dst.GetPixel(2, 2)
+	if r != 1 {
+		t.Errorf("Expected 1, got %f\n", r)
+	}
+}
+
+func TestAlphaRoundTrip(t *testing.T) {
+	bmp := NewBitmap(2, 2)
+	bmp.SetPixelRGBA(1, 1, 1, 0, 0, 102.0/255)
+	var buff bytes.Buffer
+	bmp.Encode(&buff, FormatPng)
+	decoded, err := Decode(&buff)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v\n", err)
+	}
+	_, _, _, a := decoded.GetPixelRGBA(1, 1)
+	if !blmath.Equalish(a, 102.0/255, 0.00001) {
+		t.Errorf("Expected alpha %f, got %f\n", 102.0/255, a)
+	}
+}
@@ -0,0 +1,34 @@
+// Package bitmap creates bitmap images.
+package bitmap
+
+// Channel selects a single value from each pixel of a bitmap.
+type Channel int
+
+const (
+	// ChannelRed is the red value of a pixel.
+	ChannelRed Channel = iota
+	// ChannelGreen is the green value of a pixel.
+	ChannelGreen
+	// ChannelBlue is the blue value of a pixel.
+	ChannelBlue
+	// ChannelAlpha is the alpha value of a pixel.
+	ChannelAlpha
+	// ChannelGray is the luma of a pixel, the same value used when saving a PGM.
+	ChannelGray
+)
+
+// GetChannel returns a single channel value of the pixel at the given coords.
+func (c *Bitmap) GetChannel(x, y int, channel Channel) float64 {
+	r, g, b, a := c.GetPixelRGBA(x, y)
+	switch channel {
+	case ChannelRed:
+		return r
+	case ChannelGreen:
+		return g
+	case ChannelBlue:
+		return b
+	case ChannelAlpha:
+		return a
+	}
+	return r*0.299 + g*0.587 + b*0.114
+}
@@ -0,0 +1,279 @@
+// Package bitmap creates bitmap images.
+package bitmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"math/bits"
+	"os"
+)
+
+// ErrUnknownFormat is returned when decoding data that is not in a supported image format.
+var ErrUnknownFormat = errors.New("bitmap: unknown image format")
+
+// maxDecodePixels is the largest number of pixels that will be decoded from a BMP or PPM file,
+// so a bad header can't ask for huge amounts of memory.
+const maxDecodePixels = 1 << 26
+
+// Load loads an image file into a new Bitmap.
+// The format is detected from the file contents, not the extension.
+func Load(filepath string) (*Bitmap, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Decode(file)
+}
+
+// Decode reads a BMP, PNG, PPM or PGM image into a new Bitmap.
+func Decode(r io.Reader) (*Bitmap, error) {
+	buff := bufio.NewReader(r)
+	magic, err := buff.Peek(2)
+	if err != nil {
+		return nil, ErrUnknownFormat
+	}
+	switch {
+	case magic[0] == 'B' && magic[1] == 'M':
+		return ReadBmp(buff)
+	case magic[0] == 'P' && (magic[1] == '5' || magic[1] == '6'):
+		return ReadPpm(buff)
+	case magic[0] == 0x89 && magic[1] == 'P':
+		return ReadPng(buff)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// ReadBmp reads an uncompressed 24-bit or 32-bit BMP image into a new Bitmap.
+// Alpha is read from 32-bit images only when the file specifies an alpha channel mask.
+func ReadBmp(r io.Reader) (*Bitmap, error) {
+	var header [14]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if header[0] != 'B' || header[1] != 'M' {
+		return nil, errors.New("bitmap: not a bmp file")
+	}
+	dataOffset := int(binary.LittleEndian.Uint32(header[10:]))
+
+	var infoSize uint32
+	if err := binary.Read(r, binary.LittleEndian, &infoSize); err != nil {
+		return nil, err
+	}
+	if infoSize < 40 || infoSize > 1024 {
+		return nil, fmt.Errorf("bitmap: unsupported bmp info header size %d", infoSize)
+	}
+	info := make([]byte, infoSize-4)
+	if _, err := io.ReadFull(r, info); err != nil {
+		return nil, err
+	}
+	width := int(int32(binary.LittleEndian.Uint32(info[0:])))
+	height := int(int32(binary.LittleEndian.Uint32(info[4:])))
+	depth := int(binary.LittleEndian.Uint16(info[10:]))
+	compression := binary.LittleEndian.Uint32(info[12:])
+	read := 14 + int(infoSize)
+
+	// default r, g, b, a channel masks for 32-bit pixels. without an alpha mask, pixels are opaque.
+	masks := [4]uint32{0x00ff0000, 0x0000ff00, 0x000000ff, 0}
+	switch {
+	case compression == 0 && (depth == 24 || depth == 32):
+	case compression == 3 && depth == 32:
+		if infoSize >= 52 {
+			// masks are part of the V2+ info header, with alpha from V3 on
+			count := 3
+			if infoSize >= 56 {
+				count = 4
+			}
+			for i := 0; i < count; i++ {
+				masks[i] = binary.LittleEndian.Uint32(info[36+i*4:])
+			}
+		} else {
+			// masks follow the info header
+			if err := binary.Read(r, binary.LittleEndian, masks[:3]); err != nil {
+				return nil, err
+			}
+			read += 12
+		}
+	default:
+		return nil, fmt.Errorf("bitmap: unsupported bmp depth %d, compression %d", depth, compression)
+	}
+	if height == 0 {
+		return nil, fmt.Errorf("bitmap: invalid bmp size %d x %d", width, height)
+	}
+	if dataOffset < read {
+		return nil, errors.New("bitmap: invalid bmp data offset")
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(dataOffset-read)); err != nil {
+		return nil, err
+	}
+
+	// a positive height means rows are stored from bottom to top.
+	bottomUp := height > 0
+	if height < 0 {
+		height = -height
+	}
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	bytesPerPixel := depth / 8
+	rowSize := (width*bytesPerPixel + 3) &^ 3
+	data, err := readData(r, rowSize*height)
+	if err != nil {
+		return nil, err
+	}
+	bmp := NewBitmap(width, height)
+	for i := 0; i < height; i++ {
+		row := data[i*rowSize:]
+		y := i
+		if bottomUp {
+			y = height - 1 - i
+		}
+		for x := 0; x < width; x++ {
+			p := row[x*bytesPerPixel:]
+			if depth == 24 {
+				bmp.SetPixel(x, y, float64(p[2])/255, float64(p[1])/255, float64(p[0])/255)
+				continue
+			}
+			val := binary.LittleEndian.Uint32(p)
+			a := 1.0
+			if masks[3] != 0 {
+				a = maskChannel(val, masks[3])
+			}
+			bmp.SetPixelRGBA(x, y, maskChannel(val, masks[0]), maskChannel(val, masks[1]), maskChannel(val, masks[2]), a)
+		}
+	}
+	return bmp, nil
+}
+
+// maskChannel extracts a channel from a pixel value using a bit mask and scales it from 0.0 to 1.0.
+func maskChannel(val, mask uint32) float64 {
+	if mask == 0 {
+		return 0
+	}
+	shift := bits.TrailingZeros32(mask)
+	max := mask >> shift
+	return float64((val&mask)>>shift) / float64(max)
+}
+
+// ReadPng reads a PNG image into a new Bitmap.
+func ReadPng(r io.Reader) (*Bitmap, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return FromImage(img), nil
+}
+
+// ReadPpm reads a binary PPM (P6) or PGM (P5) image into a new Bitmap.
+// Max values above 255 are read as 16-bit big endian samples.
+func ReadPpm(r io.Reader) (*Bitmap, error) {
+	buff := bufio.NewReader(r)
+	magic, err := readPnmToken(buff)
+	if err != nil {
+		return nil, err
+	}
+	if magic != "P5" && magic != "P6" {
+		return nil, errors.New("bitmap: not a binary ppm or pgm file")
+	}
+	var header [3]int
+	for i := range header {
+		token, err := readPnmToken(buff)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fmt.Sscan(token, &header[i]); err != nil {
+			return nil, fmt.Errorf("bitmap: invalid ppm header: %w", err)
+		}
+	}
+	width, height, maxVal := header[0], header[1], header[2]
+	if maxVal <= 0 || maxVal > 65535 {
+		return nil, errors.New("bitmap: invalid ppm header")
+	}
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+
+	channels := 3
+	if magic == "P5" {
+		channels = 1
+	}
+	sampleSize := 1
+	if maxVal > 255 {
+		sampleSize = 2
+	}
+	data, err := readData(buff, width*height*channels*sampleSize)
+	if err != nil {
+		return nil, err
+	}
+	sample := func(i int) float64 {
+		if sampleSize == 2 {
+			return float64(binary.BigEndian.Uint16(data[i*2:])) / float64(maxVal)
+		}
+		return float64(data[i]) / float64(maxVal)
+	}
+
+	bmp := NewBitmap(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := (y*width + x) * channels
+			if channels == 1 {
+				bmp.SetPixelGray(x, y, sample(i))
+			} else {
+				bmp.SetPixel(x, y, sample(i), sample(i+1), sample(i+2))
+			}
+		}
+	}
+	return bmp, nil
+}
+
+// readPnmToken reads the next whitespace delimited header token, skipping comments.
+// It consumes the single whitespace character following the token.
+func readPnmToken(r *bufio.Reader) (string, error) {
+	var token bytes.Buffer
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if b == '#' {
+			if _, err := r.ReadBytes('\n'); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if b == ' ' || b == '\t' || b == '\n' || b == '\r' {
+			if token.Len() > 0 {
+				return token.String(), nil
+			}
+			continue
+		}
+		token.WriteByte(b)
+	}
+}
+
+// checkSize returns an error if the width and height from a header are not positive, or are too large to decode.
+func checkSize(width, height int) error {
+	if width <= 0 || height <= 0 || width > maxDecodePixels/height {
+		return fmt.Errorf("bitmap: invalid image size %d x %d", width, height)
+	}
+	return nil
+}
+
+// readData reads size bytes of pixel data.
+// The buffer grows as the data arrives, so a header that claims more data than there is fails without
+// allocating all of it first.
+func readData(r io.Reader, size int) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
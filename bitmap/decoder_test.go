@@ -0,0 +1,120 @@
+// Package bitmap creates bitmap images.
+package bitmap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+func testBitmap() *Bitmap {
+	bmp := NewBitmap(5, 3)
+	for y := 0; y < bmp.Height; y++ {
+		for x := 0; x < bmp.Width; x++ {
+			bmp.SetPixel(x, y, float64(x*50)/255, float64(y*100)/255, float64(x*y*10)/255)
+		}
+	}
+	return bmp
+}
+
+func pixelsEqual(t *testing.T, expected, actual *Bitmap) {
+	t.Helper()
+	if expected.Width != actual.Width || expected.Height != actual.Height {
+		t.Fatalf("Expected %dx%d, got %dx%d\n", expected.Width, expected.Height, actual.Width, actual.Height)
+	}
+	for y := 0; y < expected.Height; y++ {
+		for x := 0; x < expected.Width; x++ {
+			r0, g0, b0 := expected.GetPixel(x, y)
+			r1, g1, b1 := actual.GetPixel(x, y)
+			if !blmath.Equalish(r0, r1, 0.00001) || !blmath.Equalish(g0, g1, 0.00001) || !blmath.Equalish(b0, b1, 0.00001) {
+				t.Fatalf("Expected %f, %f, %f at %d, %d, got %f, %f, %f\n", r0, g0, b0, x, y, r1, g1, b1)
+			}
+		}
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	bmp := testBitmap()
+	for _, format := range []Format{FormatBmp, FormatPng, FormatPpm} {
+		var buff bytes.Buffer
+		bmp.Encode(&buff, format)
+		decoded, err := Decode(&buff)
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v\n", format, err)
+		}
+		pixelsEqual(t, bmp, decoded)
+	}
+}
+
+func TestDecodePgm(t *testing.T) {
+	data := []byte("P5\n# comment\n2 1\n255\n\x00\xff")
+	bmp, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v\n", err)
+	}
+	r, g, b := bmp.GetPixel(1, 0)
+	if r != 1 || g != 1 || b != 1 {
+		t.Errorf("Expected 1, 1, 1, got %f, %f, %f\n", r, g, b)
+	}
+}
+
+func TestDecodeBmp32(t *testing.T) {
+	// 2x1 bottom up 32-bit bmp
+	var buff bytes.Buffer
+	buff.Write([]byte("BM"))
+	binary.Write(&buff, binary.LittleEndian, []uint32{54 + 8, 0, 54, 40})
+	binary.Write(&buff, binary.LittleEndian, []int32{2, 1})
+	binary.Write(&buff, binary.LittleEndian, []uint16{1, 32})
+	binary.Write(&buff, binary.LittleEndian, []uint32{0, 8, 0, 0, 0, 0})
+	buff.Write([]byte{255, 0, 0, 255, 0, 0, 255, 255})
+
+	bmp, err := Decode(&buff)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v\n", err)
+	}
+	expected := NewBitmap(2, 1)
+	expected.SetPixel(0, 0, 0, 0, 1)
+	expected.SetPixel(1, 0, 1, 0, 0)
+	pixelsEqual(t, expected, bmp)
+}
+
+func TestDecodeUnknown(t *testing.T) {
+	_, err := Decode(bytes.NewReader([]byte("GIF89a")))
+	if err != ErrUnknownFormat {
+		t.Errorf("Expected %v, got %v\n", ErrUnknownFormat, err)
+	}
+}
+
+func TestDecodeBadSize(t *testing.T) {
+	// bmpHeader returns the headers of a 24-bit bmp with the given size and no pixel data.
+	bmpHeader := func(width, height int32) []byte {
+		var buff bytes.Buffer
+		buff.Write([]byte("BM"))
+		binary.Write(&buff, binary.LittleEndian, []uint32{54, 0, 54, 40})
+		binary.Write(&buff, binary.LittleEndian, []int32{width, height})
+		binary.Write(&buff, binary.LittleEndian, []uint16{1, 24})
+		binary.Write(&buff, binary.LittleEndian, []uint32{0, 0, 0, 0, 0, 0})
+		return buff.Bytes()
+	}
+	tests := map[string][]byte{
+		"ppm negative width":   []byte("P6\n-2 2\n255\n"),
+		"ppm zero height":      []byte("P6\n2 0\n255\n"),
+		"ppm overflow":         []byte("P6\n4611686018427387904 4\n255\n"),
+		"ppm too large":        []byte("P6\n100000 100000\n255\n"),
+		"ppm short data":       []byte("P6\n2000 2000\n255\n\x00\x00\x00"),
+		"bmp negative width":   bmpHeader(-2, 2),
+		"bmp zero height":      bmpHeader(2, 0),
+		"bmp too large":        bmpHeader(100000, -100000),
+		"bmp largest negative": bmpHeader(2, math.MinInt32),
+		"bmp short data":       append(bmpHeader(2000, 2000), 0, 0, 0),
+	}
+	for name, data := range tests {
+		_, err := Decode(bytes.NewReader(data))
+		if err == nil {
+			t.Errorf("Expected error for %s, got nil\n", name)
+		}
+	}
+}
@@ -2,38 +2,176 @@
 package bitmap
 
 import (
-	"bytes"
+	"bufio"
 	"encoding/binary"
+	"fmt"
+	"image/png"
+	"io"
+	"math"
 	"os"
 )
 
-// EncodeBmp encodes a bitmap.
-func EncodeBmp(pixelData []float64, w, h int, filepath string) {
-	var data []byte
-	buff := bytes.NewBuffer(data)
-	size := uint32(54 + len(pixelData))
+// WriteBmp writes pixel data to a writer as a 24-bit BMP.
+// pixelData is in the same RGBA layout as Bitmap.Pixels. Alpha is discarded.
+// A negative height indicates that rows are stored from top to bottom.
+func WriteBmp(w io.Writer, pixelData []float64, width, height int) error {
+	rows := height
+	if rows < 0 {
+		rows = -rows
+	}
+	// each row is padded to a multiple of 4 bytes
+	rowSize := (width*3 + 3) &^ 3
+	padding := make([]byte, rowSize-width*3)
+	rawSize := uint32(rowSize * rows)
+
+	buff := bufio.NewWriter(w)
 
 	// bmp header - 14 bytes
-	buff.Write([]byte("BM"))                              // bitmap signature
-	binary.Write(buff, binary.LittleEndian, uint32(size)) // bmp size
-	binary.Write(buff, binary.LittleEndian, uint32(0))    // reserved
-	binary.Write(buff, binary.LittleEndian, uint32(54))   // offset for headers
+	buff.Write([]byte("BM"))                                    // bitmap signature
+	binary.Write(buff, binary.LittleEndian, uint32(54)+rawSize) // bmp size
+	binary.Write(buff, binary.LittleEndian, uint32(0))          // reserved
+	binary.Write(buff, binary.LittleEndian, uint32(54))         // offset for headers
 
 	// bmp info header - 40 bytes
-	binary.Write(buff, binary.LittleEndian, uint32(40))  // info header size
-	binary.Write(buff, binary.LittleEndian, int32(w))    // width
-	binary.Write(buff, binary.LittleEndian, int32(h))    // height
-	binary.Write(buff, binary.LittleEndian, uint16(1))   // color planes
-	binary.Write(buff, binary.LittleEndian, uint16(24))  // color depth
-	binary.Write(buff, binary.LittleEndian, uint32(0))   // compression method
-	binary.Write(buff, binary.LittleEndian, uint32(0))   // raw size (ignored)
-	binary.Write(buff, binary.LittleEndian, int32(3780)) // vert res - pix per meter
-	binary.Write(buff, binary.LittleEndian, int32(3780)) // vert res - pix per meter
-	binary.Write(buff, binary.LittleEndian, uint32(0))   // color table entries
-	binary.Write(buff, binary.LittleEndian, uint32(0))   // important colors
-
-	for _, p := range pixelData {
-		binary.Write(buff, binary.LittleEndian, uint8(p*255))
-	}
-	os.WriteFile(filepath, buff.Bytes(), 0777)
+	binary.Write(buff, binary.LittleEndian, uint32(40))    // info header size
+	binary.Write(buff, binary.LittleEndian, int32(width))  // width
+	binary.Write(buff, binary.LittleEndian, int32(height)) // height
+	binary.Write(buff, binary.LittleEndian, uint16(1))     // color planes
+	binary.Write(buff, binary.LittleEndian, uint16(24))    // color depth
+	binary.Write(buff, binary.LittleEndian, uint32(0))     // compression method
+	binary.Write(buff, binary.LittleEndian, rawSize)       // raw size
+	binary.Write(buff, binary.LittleEndian, int32(3780))   // vert res - pix per meter
+	binary.Write(buff, binary.LittleEndian, int32(3780))   // vert res - pix per meter
+	binary.Write(buff, binary.LittleEndian, uint32(0))     // color table entries
+	binary.Write(buff, binary.LittleEndian, uint32(0))     // important colors
+
+	for y := 0; y < rows; y++ {
+		for x := 0; x < width; x++ {
+			i := (y*width + x) * 4
+			buff.WriteByte(channelByte(pixelData[i+2]))
+			buff.WriteByte(channelByte(pixelData[i+1]))
+			buff.WriteByte(channelByte(pixelData[i]))
+		}
+		buff.Write(padding)
+	}
+	return buff.Flush()
+}
+
+// WritePng writes pixel data to a writer as a 32-bit RGBA PNG.
+// pixelData is in the same RGBA layout as Bitmap.Pixels.
+func WritePng(w io.Writer, pixelData []float64, width, height int) error {
+	bmp := &Bitmap{width, height, pixelData[:width*height*4]}
+	return png.Encode(w, bmp.ToNRGBA())
+}
+
+// WritePpm writes pixel data to a writer as a binary (P6) PPM.
+// pixelData is in the same RGBA layout as Bitmap.Pixels. Alpha is discarded.
+func WritePpm(w io.Writer, pixelData []float64, width, height int) error {
+	buff := bufio.NewWriter(w)
+	fmt.Fprintf(buff, "P6\n%d %d\n255\n", width, height)
+	for i := 0; i < width*height; i++ {
+		buff.WriteByte(channelByte(pixelData[i*4]))
+		buff.WriteByte(channelByte(pixelData[i*4+1]))
+		buff.WriteByte(channelByte(pixelData[i*4+2]))
+	}
+	return buff.Flush()
+}
+
+// WritePgm writes pixel data to a writer as a binary (P5) grayscale PGM.
+// Each pixel is converted to gray using its luma value.
+// pixelData is in the same RGBA layout as Bitmap.Pixels. Alpha is discarded.
+func WritePgm(w io.Writer, pixelData []float64, width, height int) error {
+	buff := bufio.NewWriter(w)
+	fmt.Fprintf(buff, "P5\n%d %d\n255\n", width, height)
+	for i := 0; i < width*height; i++ {
+		r := pixelData[i*4]
+		g := pixelData[i*4+1]
+		b := pixelData[i*4+2]
+		buff.WriteByte(channelByte(r*0.299 + g*0.587 + b*0.114))
+	}
+	return buff.Flush()
+}
+
+// WriteTga writes pixel data to a writer as an uncompressed 32-bit TGA with alpha.
+// pixelData is in the same RGBA layout as Bitmap.Pixels.
+// TGA stores sizes in 16 bits, so an error is returned for images over 65535 pixels wide or high.
+func WriteTga(w io.Writer, pixelData []float64, width, height int) error {
+	if width < 0 || height < 0 || width > math.MaxUint16 || height > math.MaxUint16 {
+		return fmt.Errorf("bitmap: invalid tga size %d x %d, the limit is 65535 x 65535", width, height)
+	}
+	buff := bufio.NewWriter(w)
+
+	// tga header - 18 bytes
+	buff.WriteByte(0)                                       // id length
+	buff.WriteByte(0)                                       // no color map
+	buff.WriteByte(2)                                       // uncompressed true color
+	buff.Write(make([]byte, 5))                             // color map spec (unused)
+	binary.Write(buff, binary.LittleEndian, uint16(0))      // x origin
+	binary.Write(buff, binary.LittleEndian, uint16(0))      // y origin
+	binary.Write(buff, binary.LittleEndian, uint16(width))  // width
+	binary.Write(buff, binary.LittleEndian, uint16(height)) // height
+	buff.WriteByte(32)                                      // color depth
+	buff.WriteByte(0x28)                                    // descriptor: top left origin, 8 alpha bits
+
+	for i := 0; i < width*height; i++ {
+		buff.WriteByte(channelByte(pixelData[i*4+2]))
+		buff.WriteByte(channelByte(pixelData[i*4+1]))
+		buff.WriteByte(channelByte(pixelData[i*4]))
+		buff.WriteByte(channelByte(pixelData[i*4+3]))
+	}
+	return buff.Flush()
+}
+
+// EncodeBmp encodes a bitmap and saves it as a BMP file.
+func EncodeBmp(pixelData []float64, w, h int, filepath string) error {
+	return encodeFile(filepath, func(out io.Writer) error {
+		return WriteBmp(out, pixelData, w, h)
+	})
+}
+
+// EncodePng encodes a bitmap and saves it as a 32-bit RGBA PNG file.
+func EncodePng(pixelData []float64, w, h int, filepath string) error {
+	return encodeFile(filepath, func(out io.Writer) error {
+		return WritePng(out, pixelData, w, h)
+	})
+}
+
+// EncodePpm encodes a bitmap and saves it as a binary (P6) PPM file.
+func EncodePpm(pixelData []float64, w, h int, filepath string) error {
+	return encodeFile(filepath, func(out io.Writer) error {
+		return WritePpm(out, pixelData, w, h)
+	})
+}
+
+// EncodePgm encodes a bitmap and saves it as a binary (P5) grayscale PGM file.
+func EncodePgm(pixelData []float64, w, h int, filepath string) error {
+	return encodeFile(filepath, func(out io.Writer) error {
+		return WritePgm(out, pixelData, w, h)
+	})
+}
+
+// EncodeTga encodes a bitmap and saves it as an uncompressed 32-bit TGA file.
+func EncodeTga(pixelData []float64, w, h int, filepath string) error {
+	return encodeFile(filepath, func(out io.Writer) error {
+		return WriteTga(out, pixelData, w, h)
+	})
+}
+
+// encodeFile creates a file and writes to it with the given encode func.
+// Errors from the encoder and from closing the file are both reported.
+func encodeFile(filepath string, encode func(io.Writer) error) error {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	if err := encode(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// channelByte converts a channel value from 0.0 - 1.0 to a byte.
+func channelByte(val float64) uint8 {
+	return uint8(clamp(val) * 255)
 }
@@ -0,0 +1,167 @@
+// Package bitmap creates bitmap images.
+package bitmap
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// failWriter accepts a limited number of bytes before failing.
+type failWriter struct {
+	remaining int
+}
+
+func (f *failWriter) Write(p []byte) (int, error) {
+	if len(p) > f.remaining {
+		n := f.remaining
+		f.remaining = 0
+		return n, errors.New("write failed")
+	}
+	f.remaining -= len(p)
+	return len(p), nil
+}
+
+func TestEncodeSize(t *testing.T) {
+	type test struct {
+		format   Format
+		expected int
+	}
+	// 5x3 pixels. bmp rows are padded from 15 to 16 bytes.
+	tests := []test{
+		{FormatBmp, 54 + 16*3},
+		{FormatPpm, len("P6\n5 3\n255\n") + 5*3*3},
+		{FormatPgm, len("P5\n5 3\n255\n") + 5*3},
+		{FormatTga, 18 + 5*3*4},
+	}
+	bmp := NewBitmap(5, 3)
+	for _, tc := range tests {
+		var buff bytes.Buffer
+		err := bmp.Encode(&buff, tc.format)
+		if err != nil {
+			t.Errorf("Expected no error for %s, got %v\n", tc.format, err)
+		}
+		if buff.Len() != tc.expected {
+			t.Errorf("Expected %d bytes for %s, got %d\n", tc.expected, tc.format, buff.Len())
+		}
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	bmp := NewBitmap(64, 64)
+	for _, format := range []Format{FormatBmp, FormatPng, FormatPpm, FormatPgm, FormatTga} {
+		err := bmp.Encode(&failWriter{100}, format)
+		if err == nil {
+			t.Errorf("Expected error for %s, got nil\n", format)
+		}
+	}
+	err := bmp.Encode(&bytes.Buffer{}, Format(-1))
+	if err == nil {
+		t.Errorf("Expected error for unknown format, got nil\n")
+	}
+}
+
+func TestSaveImageError(t *testing.T) {
+	bmp := NewBitmap(4, 4)
+	err := bmp.SaveImage(t.TempDir() + "/missing/dir/out.png")
+	if err == nil {
+		t.Errorf("Expected error, got nil\n")
+	}
+}
+
+func TestSaveImageExtension(t *testing.T) {
+	bmp := NewBitmap(4, 4)
+	dir := t.TempDir()
+	for _, name := range []string{"out.jpg", "out.webp", "out"} {
+		err := bmp.SaveImage(dir + "/" + name)
+		if err == nil {
+			t.Errorf("Expected error for %s, got nil\n", name)
+		}
+		if _, statErr := os.Stat(dir + "/" + name); statErr == nil {
+			t.Errorf("Expected no file to be written for %s\n", name)
+		}
+	}
+	for _, name := range []string{"out.png", "out.TGA"} {
+		err := bmp.SaveImage(dir + "/" + name)
+		if err != nil {
+			t.Errorf("Expected no error for %s, got %v\n", name, err)
+		}
+	}
+	// SaveImageAs ignores the extension.
+	err := bmp.SaveImageAs(dir+"/out.jpg", FormatPng)
+	if err != nil {
+		t.Errorf("Expected no error, got %v\n", err)
+	}
+}
+
+func TestWriters(t *testing.T) {
+	type test struct {
+		name   string
+		write  func(w io.Writer, pixelData []float64, width, height int) error
+		header []byte
+	}
+	tests := []test{
+		{"bmp", WriteBmp, []byte("BM")},
+		{"png", WritePng, []byte("\x89PNG")},
+		{"ppm", WritePpm, []byte("P6\n3 2\n255\n")},
+		{"pgm", WritePgm, []byte("P5\n3 2\n255\n")},
+		{"tga", WriteTga, []byte{0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 0, 2, 0, 32}},
+	}
+	bmp := NewBitmap(3, 2)
+	bmp.Clear(1, 0.5, 0)
+	for _, tc := range tests {
+		var buff bytes.Buffer
+		err := tc.write(&buff, bmp.Pixels, 3, 2)
+		if err != nil {
+			t.Errorf("Expected no error for %s, got %v\n", tc.name, err)
+		}
+		if !bytes.HasPrefix(buff.Bytes(), tc.header) {
+			t.Errorf("Expected %s to start with %q, got %q\n", tc.name, tc.header, buff.Bytes()[:min(buff.Len(), len(tc.header))])
+		}
+	}
+	// the first ppm pixel follows the header.
+	var buff bytes.Buffer
+	WritePpm(&buff, bmp.Pixels, 3, 2)
+	pixel := buff.Bytes()[len("P6\n3 2\n255\n"):][:3]
+	if pixel[0] != 255 || pixel[1] != 127 || pixel[2] != 0 {
+		t.Errorf("Expected 255 127 0, got %v\n", pixel)
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	type test struct {
+		filename string
+		format   Format
+		ok       bool
+	}
+	tests := []test{
+		{"out.bmp", FormatBmp, true},
+		{"out.PNG", FormatPng, true},
+		{"dir/out.ppm", FormatPpm, true},
+		{"out.pgm", FormatPgm, true},
+		{"out.tga", FormatTga, true},
+		{"out.jpg", FormatBmp, false},
+		{"out", FormatBmp, false},
+	}
+	for _, tc := range tests {
+		format, ok := FormatFromFilename(tc.filename)
+		if format != tc.format || ok != tc.ok {
+			t.Errorf("Expected %s, %t for %s, got %s, %t\n", tc.format, tc.ok, tc.filename, format, ok)
+		}
+	}
+}
+
+func TestWriteTgaTooLarge(t *testing.T) {
+	for _, size := range [][2]int{{65536, 1}, {1, 70000}} {
+		var buff bytes.Buffer
+		err := WriteTga(&buff, nil, size[0], size[1])
+		if err == nil {
+			t.Errorf("Expected error for %d x %d, got nil\n", size[0], size[1])
+		}
+		if buff.Len() != 0 {
+			t.Errorf("Expected nothing to be written, got %d bytes\n", buff.Len())
+		}
+	}
+}
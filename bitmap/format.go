@@ -0,0 +1,58 @@
+// Package bitmap creates bitmap images.
+package bitmap
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Format represents an image file format that a bitmap can be saved as.
+type Format int
+
+const (
+	// FormatBmp is a 24-bit uncompressed BMP file.
+	FormatBmp Format = iota
+	// FormatPng is a 32-bit RGBA PNG file.
+	FormatPng
+	// FormatPpm is a binary (P6) PPM file.
+	FormatPpm
+	// FormatPgm is a binary (P5) grayscale PGM file.
+	FormatPgm
+	// FormatTga is an uncompressed 32-bit TGA file with alpha.
+	FormatTga
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case FormatBmp:
+		return "bmp"
+	case FormatPng:
+		return "png"
+	case FormatPpm:
+		return "ppm"
+	case FormatPgm:
+		return "pgm"
+	case FormatTga:
+		return "tga"
+	}
+	return "unknown"
+}
+
+// FormatFromFilename returns the format matching the extension of the given file name.
+// The bool return value is false if the extension is not recognized.
+func FormatFromFilename(filename string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".bmp":
+		return FormatBmp, true
+	case ".png":
+		return FormatPng, true
+	case ".ppm":
+		return FormatPpm, true
+	case ".pgm":
+		return FormatPgm, true
+	case ".tga":
+		return FormatTga, true
+	}
+	return FormatBmp, false
+}
@@ -0,0 +1,75 @@
+// Package bitmap creates bitmap images.
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Bitmap implements draw.Image, so it can be used anywhere an image.Image is expected.
+var _ draw.Image = (*Bitmap)(nil)
+
+// ColorModel returns the color model of the bitmap, for image.Image.
+func (c *Bitmap) ColorModel() color.Model {
+	return color.NRGBA64Model
+}
+
+// Bounds returns the bounds of the bitmap, for image.Image.
+func (c *Bitmap) Bounds() image.Rectangle {
+	return image.Rect(0, 0, c.Width, c.Height)
+}
+
+// At returns the color of the pixel at the given coords, for image.Image.
+func (c *Bitmap) At(x, y int) color.Color {
+	r, g, b, a := c.GetPixelRGBA(x, y)
+	return color.NRGBA64{
+		R: channelUint16(r),
+		G: channelUint16(g),
+		B: channelUint16(b),
+		A: channelUint16(a),
+	}
+}
+
+// Set sets the color of the pixel at the given coords, for draw.Image.
+func (c *Bitmap) Set(x, y int, col color.Color) {
+	n := color.NRGBA64Model.Convert(col).(color.NRGBA64)
+	c.SetPixelRGBA(x, y, float64(n.R)/0xffff, float64(n.G)/0xffff, float64(n.B)/0xffff, float64(n.A)/0xffff)
+}
+
+// FromImage creates a new Bitmap from any image.Image.
+// The top left of the image's bounds becomes 0, 0 in the bitmap.
+func FromImage(img image.Image) *Bitmap {
+	bounds := img.Bounds()
+	bmp := NewBitmap(bounds.Dx(), bounds.Dy())
+	if src, ok := img.(*image.NRGBA); ok {
+		for y := 0; y < bmp.Height; y++ {
+			for x := 0; x < bmp.Width; x++ {
+				i := src.PixOffset(x+bounds.Min.X, y+bounds.Min.Y)
+				p := src.Pix[i : i+4]
+				bmp.SetPixelRGBA(x, y, float64(p[0])/255, float64(p[1])/255, float64(p[2])/255, float64(p[3])/255)
+			}
+		}
+		return bmp
+	}
+	for y := 0; y < bmp.Height; y++ {
+		for x := 0; x < bmp.Width; x++ {
+			bmp.Set(x, y, img.At(x+bounds.Min.X, y+bounds.Min.Y))
+		}
+	}
+	return bmp
+}
+
+// ToNRGBA converts the bitmap to an 8-bit image.NRGBA.
+func (c *Bitmap) ToNRGBA() *image.NRGBA {
+	img := image.NewNRGBA(c.Bounds())
+	for i, p := range c.Pixels {
+		img.Pix[i] = channelByte(p)
+	}
+	return img
+}
+
+// channelUint16 converts a channel value from 0.0 - 1.0 to a 16-bit value.
+func channelUint16(val float64) uint16 {
+	return uint16(clamp(val)*0xffff + 0.5)
+}
@@ -0,0 +1,54 @@
+// Package bitmap creates bitmap images.
+package bitmap
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/bit101/bitlib/blcolor"
+)
+
+func TestDrawOnBitmap(t *testing.T) {
+	bmp := NewBitmap(4, 4)
+	red := image.NewUniform(color.NRGBA{255, 0, 0, 255})
+	draw.Draw(bmp, image.Rect(1, 1, 3, 3), red, image.Point{}, draw.Src)
+
+	result := bmp.GetPixelColor(1, 1)
+	if !result.Equals(blcolor.RGB(1, 0, 0)) {
+		t.Errorf("Expected red, got %v\n", result)
+	}
+	result = bmp.GetPixelColor(0, 0)
+	if !result.Equals(blcolor.RGB(0, 0, 0)) {
+		t.Errorf("Expected black, got %v\n", result)
+	}
+}
+
+func TestFromImage(t *testing.T) {
+	// offset bounds and premultiplied source colors
+	img := image.NewRGBA(image.Rect(10, 10, 13, 12))
+	img.Set(10, 10, color.RGBA{0, 0, 128, 128})
+	img.Set(12, 11, color.RGBA{255, 255, 255, 255})
+
+	bmp := FromImage(img)
+	if bmp.Width != 3 || bmp.Height != 2 {
+		t.Fatalf("Expected 3x2, got %dx%d\n", bmp.Width, bmp.Height)
+	}
+	r, g, b, a := bmp.GetPixelRGBA(0, 0)
+	if r != 0 || g != 0 || b != 1 || a != 128.0/255 {
+		t.Errorf("Expected 0, 0, 1, %f, got %f, %f, %f, %f\n", 128.0/255, r, g, b, a)
+	}
+	r, g, b, a = bmp.GetPixelRGBA(2, 1)
+	if r != 1 || g != 1 || b != 1 || a != 1 {
+		t.Errorf("Expected 1, 1, 1, 1, got %f, %f, %f, %f\n", r, g, b, a)
+	}
+
+	// back out again
+	out := image.NewNRGBA(bmp.Bounds())
+	draw.Draw(out, out.Bounds(), bmp, image.Point{}, draw.Src)
+	c := out.NRGBAAt(0, 0)
+	if c != (color.NRGBA{0, 0, 255, 128}) {
+		t.Errorf("Expected {0 0 255 128}, got %v\n", c)
+	}
+}
@@ -0,0 +1,361 @@
+// Package bitmap creates bitmap images.
+package bitmap
+
+import (
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/bitlib/geom"
+)
+
+// FillRule determines which areas of a self intersecting or nested path are filled.
+type FillRule int
+
+const (
+	// NonZero fills any area with a non zero winding number.
+	NonZero FillRule = iota
+	// EvenOdd fills any area that is crossed an odd number of times.
+	EvenOdd
+)
+
+// LineCap determines how the ends of open strokes are drawn.
+type LineCap int
+
+const (
+	// CapButt ends the stroke exactly at the end point.
+	CapButt LineCap = iota
+	// CapRound ends the stroke with a semicircle.
+	CapRound
+	// CapSquare extends the stroke by half the line width past the end point.
+	CapSquare
+)
+
+// LineJoin determines how the corners of strokes are drawn.
+type LineJoin int
+
+const (
+	// JoinMiter extends the outer edges of the stroke to a point, falling back to bevel past the miter limit.
+	JoinMiter LineJoin = iota
+	// JoinRound rounds off the corner.
+	JoinRound
+	// JoinBevel cuts off the corner.
+	JoinBevel
+)
+
+// subsamples is the number of sub-scanlines sampled per pixel row.
+// Horizontal coverage is computed exactly, so this only affects vertical anti-aliasing.
+const subsamples = 16
+
+// Rasterizer draws anti-aliased geom shapes onto a Bitmap.
+type Rasterizer struct {
+	Bitmap     *Bitmap
+	Color      blcolor.Color
+	LineWidth  float64
+	LineCap    LineCap
+	LineJoin   LineJoin
+	MiterLimit float64
+	FillRule   FillRule
+	// Tolerance is the max distance in pixels that curves may deviate from their polygon approximations.
+	Tolerance float64
+}
+
+// NewRasterizer creates a new Rasterizer that draws to the given bitmap in opaque black.
+func NewRasterizer(bmp *Bitmap) *Rasterizer {
+	return &Rasterizer{
+		Bitmap:     bmp,
+		Color:      blcolor.RGB(0, 0, 0),
+		LineWidth:  1,
+		LineCap:    CapButt,
+		LineJoin:   JoinMiter,
+		MiterLimit: 10,
+		FillRule:   NonZero,
+		Tolerance:  0.1,
+	}
+}
+
+//////////////////////////////
+// Fills
+//////////////////////////////
+
+// FillPath fills one or more closed sub paths using the current fill rule.
+// Sub paths can be used to create holes.
+func (r *Rasterizer) FillPath(paths ...geom.PointList) {
+	r.fill(paths, r.FillRule)
+}
+
+// FillPolygon fills a closed polygon.
+func (r *Rasterizer) FillPolygon(points geom.PointList) {
+	r.FillPath(points)
+}
+
+// FillTriangle fills a triangle.
+func (r *Rasterizer) FillTriangle(t *geom.Triangle) {
+	r.FillPath(t.Points())
+}
+
+// FillTriangleList fills all the triangles in a list.
+func (r *Rasterizer) FillTriangleList(triangles geom.TriangleList) {
+	for _, t := range triangles {
+		r.FillTriangle(t)
+	}
+}
+
+// FillCircle fills a circle.
+func (r *Rasterizer) FillCircle(c *geom.Circle) {
+	r.fill([]geom.PointList{r.circlePoints(c.X, c.Y, c.Radius)}, NonZero)
+}
+
+// FillCircleList fills all the circles in a list.
+func (r *Rasterizer) FillCircleList(circles geom.CircleList) {
+	for _, c := range circles {
+		r.FillCircle(c)
+	}
+}
+
+// FillRect fills a rectangle.
+func (r *Rasterizer) FillRect(rect *geom.Rect) {
+	r.fill([]geom.PointList{rectPoints(rect)}, NonZero)
+}
+
+//////////////////////////////
+// Strokes
+//////////////////////////////
+
+// StrokeSegment strokes a line segment.
+func (r *Rasterizer) StrokeSegment(s *geom.Segment) {
+	r.StrokePolyline(geom.PointList{s.PointA, s.PointB})
+}
+
+// StrokeSegmentList strokes all the segments in a list.
+func (r *Rasterizer) StrokeSegmentList(segments geom.SegmentList) {
+	for _, s := range segments {
+		r.StrokeSegment(s)
+	}
+}
+
+// StrokePolyline strokes an open path through the points, using the line cap at each end.
+func (r *Rasterizer) StrokePolyline(points geom.PointList) {
+	r.fill(r.strokeOutline(points, false), NonZero)
+}
+
+// StrokePolygon strokes a closed path through the points.
+func (r *Rasterizer) StrokePolygon(points geom.PointList) {
+	r.fill(r.strokeOutline(points, true), NonZero)
+}
+
+// StrokeTriangle strokes a triangle.
+func (r *Rasterizer) StrokeTriangle(t *geom.Triangle) {
+	r.StrokePolygon(t.Points())
+}
+
+// StrokeTriangleList strokes all the triangles in a list.
+func (r *Rasterizer) StrokeTriangleList(triangles geom.TriangleList) {
+	for _, t := range triangles {
+		r.StrokeTriangle(t)
+	}
+}
+
+// StrokeCircle strokes a circle.
+func (r *Rasterizer) StrokeCircle(c *geom.Circle) {
+	hw := r.LineWidth / 2
+	paths := []geom.PointList{r.circlePoints(c.X, c.Y, c.Radius+hw)}
+	if c.Radius > hw {
+		// inner circle wound the opposite way cuts a hole with the nonzero rule.
+		inner := r.circlePoints(c.X, c.Y, c.Radius-hw)
+		slices.Reverse(inner)
+		paths = append(paths, inner)
+	}
+	r.fill(paths, NonZero)
+}
+
+// StrokeCircleList strokes all the circles in a list.
+func (r *Rasterizer) StrokeCircleList(circles geom.CircleList) {
+	for _, c := range circles {
+		r.StrokeCircle(c)
+	}
+}
+
+// StrokeRect strokes a rectangle.
+func (r *Rasterizer) StrokeRect(rect *geom.Rect) {
+	r.StrokePolygon(rectPoints(rect))
+}
+
+//////////////////////////////
+// Scanline fill
+//////////////////////////////
+
+// edge is a non horizontal polygon edge, stored with y0 < y1.
+type edge struct {
+	x0, y0, x1, y1 float64
+	dir            int
+}
+
+// crossing is where an edge crosses a sub-scanline.
+type crossing struct {
+	x   float64
+	dir int
+}
+
+// fill rasterizes the closed paths and blends the coverage onto the bitmap with the current color.
+func (r *Rasterizer) fill(paths []geom.PointList, rule FillRule) {
+	bmp := r.Bitmap
+	edges := []edge{}
+	minX, maxX := math.MaxFloat64, -math.MaxFloat64
+	for _, path := range paths {
+		count := len(path)
+		for i := 0; i < count; i++ {
+			a := path[i]
+			b := path[(i+1)%count]
+			minX = math.Min(minX, a.X)
+			maxX = math.Max(maxX, a.X)
+			if a.Y == b.Y {
+				continue
+			}
+			if a.Y < b.Y {
+				edges = append(edges, edge{a.X, a.Y, b.X, b.Y, 1})
+			} else {
+				edges = append(edges, edge{b.X, b.Y, a.X, a.Y, -1})
+			}
+		}
+	}
+	if len(edges) == 0 {
+		return
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].y0 < edges[j].y0
+	})
+
+	x0 := max(0, int(math.Floor(minX)))
+	x1 := min(bmp.Width, int(math.Ceil(maxX))+1)
+	if x0 >= x1 {
+		return
+	}
+	cover := make([]float64, x1-x0)
+	y0 := max(0, int(math.Floor(edges[0].y0)))
+
+	next := 0
+	active := []edge{}
+	crossings := []crossing{}
+	for y := y0; y < bmp.Height; y++ {
+		fy := float64(y)
+		// add edges starting before the end of this row, drop edges ending before its start.
+		for next < len(edges) && edges[next].y0 < fy+1 {
+			active = append(active, edges[next])
+			next++
+		}
+		kept := active[:0]
+		for _, e := range active {
+			if e.y1 > fy {
+				kept = append(kept, e)
+			}
+		}
+		active = kept
+		if len(active) == 0 {
+			if next == len(edges) {
+				break
+			}
+			continue
+		}
+
+		for s := 0; s < subsamples; s++ {
+			sy := fy + (float64(s)+0.5)/subsamples
+			crossings = crossings[:0]
+			for _, e := range active {
+				if sy >= e.y0 && sy < e.y1 {
+					x := e.x0 + (sy-e.y0)*(e.x1-e.x0)/(e.y1-e.y0)
+					crossings = append(crossings, crossing{x, e.dir})
+				}
+			}
+			sort.Slice(crossings, func(i, j int) bool {
+				return crossings[i].x < crossings[j].x
+			})
+			winding := 0
+			start := 0.0
+			for _, c := range crossings {
+				wasInside := isInside(winding, rule)
+				winding += c.dir
+				inside := isInside(winding, rule)
+				if !wasInside && inside {
+					start = c.x
+				} else if wasInside && !inside {
+					addSpan(cover, start-float64(x0), c.x-float64(x0), 1.0/subsamples)
+				}
+			}
+		}
+
+		for i, c := range cover {
+			if c > 0 {
+				bmp.BlendPixel(x0+i, y, r.Color.R, r.Color.G, r.Color.B, r.Color.A*math.Min(c, 1))
+				cover[i] = 0
+			}
+		}
+	}
+}
+
+// isInside reports whether a winding number is inside the shape with the given fill rule.
+func isInside(winding int, rule FillRule) bool {
+	if rule == EvenOdd {
+		return winding%2 != 0
+	}
+	return winding != 0
+}
+
+// addSpan adds the coverage of a horizontal span to a row of coverage values.
+// Pixels partially covered by the span get a proportional amount.
+func addSpan(cover []float64, x0, x1, amount float64) {
+	width := float64(len(cover))
+	x0 = math.Max(0, x0)
+	x1 = math.Min(width, x1)
+	if x0 >= x1 {
+		return
+	}
+	i0 := int(x0)
+	i1 := int(x1)
+	if i0 == i1 {
+		cover[i0] += (x1 - x0) * amount
+		return
+	}
+	cover[i0] += (float64(i0+1) - x0) * amount
+	for i := i0 + 1; i < i1; i++ {
+		cover[i] += amount
+	}
+	if i1 < len(cover) {
+		cover[i1] += (x1 - float64(i1)) * amount
+	}
+}
+
+//////////////////////////////
+// Shape helpers
+//////////////////////////////
+
+// circlePoints returns a polygon approximating a circle within the rasterizer's tolerance.
+func (r *Rasterizer) circlePoints(x, y, radius float64) geom.PointList {
+	return arcPoints(x, y, radius, 0, 2*math.Pi, r.Tolerance)
+}
+
+// arcPoints returns the points along an arc, spaced so that the chords stay within tolerance of the arc.
+func arcPoints(x, y, radius, start, end, tolerance float64) geom.PointList {
+	points := geom.NewPointList()
+	if radius <= 0 {
+		return points
+	}
+	step := 2 * math.Acos(math.Max(-1, 1-tolerance/radius))
+	count := max(8, int(math.Ceil(math.Abs(end-start)/step)))
+	for i := 0; i <= count; i++ {
+		angle := start + (end-start)*float64(i)/float64(count)
+		points.AddXY(x+math.Cos(angle)*radius, y+math.Sin(angle)*radius)
+	}
+	return points
+}
+
+// rectPoints returns the four corners of a rectangle.
+func rectPoints(rect *geom.Rect) geom.PointList {
+	return geom.PointList{
+		geom.NewPoint(rect.X, rect.Y),
+		geom.NewPoint(rect.X+rect.W, rect.Y),
+		geom.NewPoint(rect.X+rect.W, rect.Y+rect.H),
+		geom.NewPoint(rect.X, rect.Y+rect.H),
+	}
+}
@@ -0,0 +1,113 @@
+// Package bitmap creates bitmap images.
+package bitmap
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+	"github.com/bit101/bitlib/geom"
+)
+
+// coverage returns the total alpha drawn onto a transparent bitmap.
+func coverage(bmp *Bitmap) float64 {
+	total := 0.0
+	for i := 3; i < len(bmp.Pixels); i += 4 {
+		total += bmp.Pixels[i]
+	}
+	return total
+}
+
+func newTestRasterizer() *Rasterizer {
+	bmp := NewBitmap(100, 100)
+	bmp.ClearRGBA(0, 0, 0, 0)
+	return NewRasterizer(bmp)
+}
+
+func TestFillRectCoverage(t *testing.T) {
+	r := newTestRasterizer()
+	r.FillRect(geom.NewRect(10.25, 20.5, 30.5, 10))
+
+	_, _, _, a := r.Bitmap.GetPixelRGBA(10, 25)
+	if !blmath.Equalish(a, 0.75, 0.00001) {
+		t.Errorf("Expected 0.75, got %f\n", a)
+	}
+	_, _, _, a = r.Bitmap.GetPixelRGBA(20, 20)
+	if !blmath.Equalish(a, 0.5, 0.00001) {
+		t.Errorf("Expected 0.5, got %f\n", a)
+	}
+	total := coverage(r.Bitmap)
+	if !blmath.Equalish(total, 305, 0.00001) {
+		t.Errorf("Expected 305, got %f\n", total)
+	}
+}
+
+func TestFillRule(t *testing.T) {
+	star := geom.NewPointList()
+	for i := 0; i < 5; i++ {
+		angle := float64(i) * 4 * math.Pi / 5
+		star.AddXY(50+40*math.Cos(angle), 50+40*math.Sin(angle))
+	}
+
+	r := newTestRasterizer()
+	r.FillPolygon(star)
+	_, _, _, a := r.Bitmap.GetPixelRGBA(50, 50)
+	if a != 1 {
+		t.Errorf("Expected nonzero center to be filled, got %f\n", a)
+	}
+
+	r = newTestRasterizer()
+	r.FillRule = EvenOdd
+	r.FillPolygon(star)
+	_, _, _, a = r.Bitmap.GetPixelRGBA(50, 50)
+	if a != 0 {
+		t.Errorf("Expected evenodd center to be empty, got %f\n", a)
+	}
+}
+
+func TestStrokeSegment(t *testing.T) {
+	type test struct {
+		lineCap  LineCap
+		expected float64
+	}
+	tests := []test{
+		{CapButt, 60 * 4},
+		{CapSquare, 64 * 4},
+		{CapRound, 60*4 + math.Pi*4},
+	}
+	for _, tc := range tests {
+		r := newTestRasterizer()
+		r.LineWidth = 4
+		r.LineCap = tc.lineCap
+		r.StrokeSegment(geom.NewSegment(20, 30.5, 80, 30.5))
+		total := coverage(r.Bitmap)
+		if !blmath.Equalish(total, tc.expected, 1) {
+			t.Errorf("Expected %f, got %f\n", tc.expected, total)
+		}
+	}
+}
+
+func TestStrokeJoin(t *testing.T) {
+	// right angle corner, width 10. bevel adds a triangle, miter a square, over the butt ended legs.
+	corner := geom.PointList{geom.NewPoint(20, 80), geom.NewPoint(20, 20), geom.NewPoint(80, 20)}
+	legs := 60.0*10*2 - 25
+	type test struct {
+		join     LineJoin
+		expected float64
+	}
+	tests := []test{
+		{JoinBevel, legs + 12.5},
+		{JoinMiter, legs + 25},
+		{JoinRound, legs + math.Pi*25/4},
+	}
+	for _, tc := range tests {
+		r := newTestRasterizer()
+		r.LineWidth = 10
+		r.LineJoin = tc.join
+		r.StrokePolyline(corner)
+		total := coverage(r.Bitmap)
+		if !blmath.Equalish(total, tc.expected, 1) {
+			t.Errorf("Expected %f, got %f\n", tc.expected, total)
+		}
+	}
+}
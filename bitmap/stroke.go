@@ -0,0 +1,172 @@
+// Package bitmap creates bitmap images.
+package bitmap
+
+import (
+	"math"
+	"slices"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// strokeOutline builds the outline of a stroked path as a set of polygons.
+// Each segment, join and cap is a separate polygon, all wound in the same direction,
+// so filling them together with the nonzero rule covers their union exactly once.
+func (r *Rasterizer) strokeOutline(points geom.PointList, closed bool) []geom.PointList {
+	hw := r.LineWidth / 2
+	pieces := []geom.PointList{}
+	if hw <= 0 {
+		return pieces
+	}
+
+	// remove repeated points, which have no direction.
+	path := geom.NewPointList()
+	for _, p := range points {
+		if len(path) == 0 || !p.Equals(path.Last()) {
+			path.Add(p)
+		}
+	}
+	if closed && len(path) > 1 && path.First().Equals(path.Last()) {
+		path = path[:len(path)-1]
+	}
+	if len(path) == 0 {
+		return pieces
+	}
+	if len(path) == 1 {
+		// a single point only shows up with round or square caps.
+		p := path[0]
+		switch r.LineCap {
+		case CapRound:
+			pieces = append(pieces, r.circlePoints(p.X, p.Y, hw))
+		case CapSquare:
+			pieces = append(pieces, rectPoints(geom.NewRect(p.X-hw, p.Y-hw, hw*2, hw*2)))
+		}
+		return pieces
+	}
+
+	count := len(path)
+	segCount := count - 1
+	if closed {
+		segCount = count
+	}
+
+	// segment bodies
+	for i := 0; i < segCount; i++ {
+		a := path[i]
+		b := path[(i+1)%count]
+		dx, dy := direction(a, b)
+		nx, ny := -dy*hw, dx*hw
+		pieces = append(pieces, geom.PointList{
+			geom.NewPoint(a.X+nx, a.Y+ny),
+			geom.NewPoint(b.X+nx, b.Y+ny),
+			geom.NewPoint(b.X-nx, b.Y-ny),
+			geom.NewPoint(a.X-nx, a.Y-ny),
+		})
+	}
+
+	// joins
+	for i := 0; i < count; i++ {
+		if !closed && (i == 0 || i == count-1) {
+			continue
+		}
+		prev := path[(i+count-1)%count]
+		p := path[i]
+		next := path[(i+1)%count]
+		if join := r.joinPoints(prev, p, next, hw); join != nil {
+			pieces = append(pieces, join)
+		}
+	}
+
+	// caps
+	if !closed {
+		if start := r.capPoints(path[1], path[0], hw); start != nil {
+			pieces = append(pieces, start)
+		}
+		if end := r.capPoints(path[count-2], path[count-1], hw); end != nil {
+			pieces = append(pieces, end)
+		}
+	}
+
+	for _, piece := range pieces {
+		if piece.SignedArea() < 0 {
+			slices.Reverse(piece)
+		}
+	}
+	return pieces
+}
+
+// joinPoints returns the polygon that fills the outer corner at p, or nil if none is needed.
+func (r *Rasterizer) joinPoints(prev, p, next *geom.Point, hw float64) geom.PointList {
+	dx0, dy0 := direction(prev, p)
+	dx1, dy1 := direction(p, next)
+	cross := dx0*dy1 - dy0*dx1
+	dot := dx0*dx1 + dy0*dy1
+	if math.Abs(cross) < 1e-9 && dot > 0 {
+		// straight through, the segments already meet.
+		return nil
+	}
+
+	if r.LineJoin == JoinRound {
+		return r.circlePoints(p.X, p.Y, hw)
+	}
+
+	// outer side offsets of the incoming and outgoing segments.
+	ox0, oy0 := -dy0*hw, dx0*hw
+	ox1, oy1 := -dy1*hw, dx1*hw
+	if cross > 0 {
+		ox0, oy0, ox1, oy1 = -ox0, -oy0, -ox1, -oy1
+	}
+	bevel := geom.PointList{
+		p.Clone(),
+		geom.NewPoint(p.X+ox0, p.Y+oy0),
+		geom.NewPoint(p.X+ox1, p.Y+oy1),
+	}
+	if r.LineJoin == JoinBevel {
+		return bevel
+	}
+
+	// miter: the tip is along the bisector of the two offsets, at hw / cos(half the turn).
+	mx, my := ox0+ox1, oy0+oy1
+	mlen := math.Hypot(mx, my)
+	if mlen < 1e-9 {
+		return bevel
+	}
+	mx, my = mx/mlen, my/mlen
+	cosHalf := (mx*ox0 + my*oy0) / hw
+	if cosHalf <= 0 || 1/cosHalf > r.MiterLimit {
+		return bevel
+	}
+	tip := hw / cosHalf
+	return geom.PointList{
+		p.Clone(),
+		geom.NewPoint(p.X+ox0, p.Y+oy0),
+		geom.NewPoint(p.X+mx*tip, p.Y+my*tip),
+		geom.NewPoint(p.X+ox1, p.Y+oy1),
+	}
+}
+
+// capPoints returns the polygon capping the stroke at end, coming from prev, or nil for butt caps.
+func (r *Rasterizer) capPoints(prev, end *geom.Point, hw float64) geom.PointList {
+	switch r.LineCap {
+	case CapRound:
+		return r.circlePoints(end.X, end.Y, hw)
+	case CapSquare:
+		dx, dy := direction(prev, end)
+		nx, ny := -dy*hw, dx*hw
+		ex, ey := dx*hw, dy*hw
+		return geom.PointList{
+			geom.NewPoint(end.X+nx, end.Y+ny),
+			geom.NewPoint(end.X+nx+ex, end.Y+ny+ey),
+			geom.NewPoint(end.X-nx+ex, end.Y-ny+ey),
+			geom.NewPoint(end.X-nx, end.Y-ny),
+		}
+	}
+	return nil
+}
+
+// direction returns the unit vector from a to b.
+func direction(a, b *geom.Point) (float64, float64) {
+	dx := b.X - a.X
+	dy := b.Y - a.Y
+	d := math.Hypot(dx, dy)
+	return dx / d, dy / d
+}
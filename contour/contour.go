@@ -0,0 +1,252 @@
+// Package contour extracts iso-contours from scalar fields using marching squares.
+package contour
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/bitmap"
+	"github.com/bit101/bitlib/blmath"
+	"github.com/bit101/bitlib/geom"
+)
+
+// Trace samples a scalar field on a grid covering bounds, with cells about res units in size,
+// and returns the contours where the field crosses level.
+// Areas with values below level are treated as inside, so a signed distance function traced at
+// level 0 gives the outline of its shape.
+// Closed contours don't repeat their first point. Open contours are those cut off by the edge of the bounds.
+// All contours run with the inside on their right, so closed contours around inside areas are
+// clockwise on screen.
+// A res of 0 or less gives no contours.
+func Trace(f func(x, y float64) float64, bounds *geom.Rect, res, level float64) (closed, open []geom.PointList) {
+	if !(res > 0) {
+		return nil, nil
+	}
+	cols := max(int(math.Ceil(bounds.W/res)), 1)
+	rows := max(int(math.Ceil(bounds.H/res)), 1)
+	g := &grid{
+		x:      bounds.X,
+		y:      bounds.Y,
+		dx:     bounds.W / float64(cols),
+		dy:     bounds.H / float64(rows),
+		cols:   cols,
+		rows:   rows,
+		level:  level,
+		values: make([]float64, (cols+1)*(rows+1)),
+	}
+	for j := 0; j <= rows; j++ {
+		for i := 0; i <= cols; i++ {
+			g.values[j*(cols+1)+i] = f(g.x+float64(i)*g.dx, g.y+float64(j)*g.dy)
+		}
+	}
+	return g.link(g.march())
+}
+
+// TraceBitmap traces contours of a single channel of a bitmap, sampling every res pixels.
+// Samples are taken at pixel centers, interpolating between them when res isn't a whole number.
+// Dark areas are inside, so contours around bright areas run counterclockwise.
+func TraceBitmap(bmp *bitmap.Bitmap, channel bitmap.Channel, res, level float64) (closed, open []geom.PointList) {
+	bounds := geom.NewRect(0.5, 0.5, float64(bmp.Width-1), float64(bmp.Height-1))
+	return Trace(BitmapField(bmp, channel), bounds, res, level)
+}
+
+// BitmapField returns a function that samples a bitmap channel at any location, interpolating
+// bilinearly between pixel centers. Locations off the edge of the bitmap get the value of the nearest edge pixel.
+func BitmapField(bmp *bitmap.Bitmap, channel bitmap.Channel) func(x, y float64) float64 {
+	return func(x, y float64) float64 {
+		x = blmath.Clamp(x-0.5, 0, float64(bmp.Width-1))
+		y = blmath.Clamp(y-0.5, 0, float64(bmp.Height-1))
+		x0 := int(x)
+		y0 := int(y)
+		x1 := min(x0+1, bmp.Width-1)
+		y1 := min(y0+1, bmp.Height-1)
+		tx := x - float64(x0)
+		ty := y - float64(y0)
+		top := blmath.Lerp(tx, bmp.GetChannel(x0, y0, channel), bmp.GetChannel(x1, y0, channel))
+		bottom := blmath.Lerp(tx, bmp.GetChannel(x0, y1, channel), bmp.GetChannel(x1, y1, channel))
+		return blmath.Lerp(ty, top, bottom)
+	}
+}
+
+//////////////////////////////
+// Marching squares
+//////////////////////////////
+
+// grid holds the sampled values of a field.
+// Edges between samples are identified by an id: horizontal edges going right from sample i, j
+// have the id (j * (cols + 1) + i) * 2, and vertical edges going down from it have that id + 1.
+type grid struct {
+	x, y, dx, dy float64
+	cols, rows   int
+	level        float64
+	values       []float64
+}
+
+// segment is a piece of contour crossing a single cell, from one edge to another.
+type segment struct {
+	start, end int
+}
+
+// corner offsets of a cell, clockwise from top left.
+var cornerX = [4]int{0, 1, 1, 0}
+var cornerY = [4]int{0, 0, 1, 1}
+
+// march finds the segments in every cell, oriented with the inside on the right.
+func (g *grid) march() []segment {
+	segments := []segment{}
+	for j := 0; j < g.rows; j++ {
+		for i := 0; i < g.cols; i++ {
+			inside := [4]bool{}
+			count := 0
+			for c := 0; c < 4; c++ {
+				inside[c] = g.inside(i+cornerX[c], j+cornerY[c])
+				if inside[c] {
+					count++
+				}
+			}
+			if count == 0 || count == 4 {
+				continue
+			}
+
+			// edges of the cell are numbered to match their starting corner: top, right, bottom, left.
+			// corner c touches edges c and c - 1.
+			crossing := []int{}
+			for e := 0; e < 4; e++ {
+				if inside[e] != inside[(e+1)%4] {
+					crossing = append(crossing, e)
+				}
+			}
+			if len(crossing) == 2 {
+				segments = append(segments, g.orient(i, j, inside, crossing[0], crossing[1]))
+				continue
+			}
+
+			// saddle: two opposite corners are inside. the value at the center of the cell decides
+			// whether they are joined, and the segments cut off whichever corners differ from the center.
+			center := (g.value(i, j) + g.value(i+1, j) + g.value(i+1, j+1) + g.value(i, j+1)) / 4
+			centerInside := center < g.level
+			for c := 0; c < 4; c++ {
+				if inside[c] != centerInside {
+					segments = append(segments, g.orient(i, j, inside, (c+3)%4, c))
+				}
+			}
+		}
+	}
+	return segments
+}
+
+// orient creates the segment in cell i, j between two of its edges, so the inside is on its right.
+// The direction is worked out from the edge midpoints of a unit cell, which is never degenerate.
+func (g *grid) orient(i, j int, inside [4]bool, e0, e1 int) segment {
+	mx0, my0 := edgeMidpoint(e0)
+	mx1, my1 := edgeMidpoint(e1)
+	// the inside corner of e0.
+	c := e0
+	if !inside[c] {
+		c = (e0 + 1) % 4
+	}
+	// right normal of the direction from e0 to e1, in y down coords.
+	nx := -(my1 - my0)
+	ny := mx1 - mx0
+	start := g.edgeID(i, j, e0)
+	end := g.edgeID(i, j, e1)
+	if (float64(cornerX[c])-mx0)*nx+(float64(cornerY[c])-my0)*ny < 0 {
+		start, end = end, start
+	}
+	return segment{start, end}
+}
+
+// edgeMidpoint returns the midpoint of an edge of a unit cell.
+func edgeMidpoint(e int) (float64, float64) {
+	a := e
+	b := (e + 1) % 4
+	return float64(cornerX[a]+cornerX[b]) / 2, float64(cornerY[a]+cornerY[b]) / 2
+}
+
+// edgeID returns the id of an edge of cell i, j.
+func (g *grid) edgeID(i, j, e int) int {
+	switch e {
+	case 0:
+		return (j*(g.cols+1) + i) * 2
+	case 1:
+		return (j*(g.cols+1)+i+1)*2 + 1
+	case 2:
+		return ((j+1)*(g.cols+1) + i) * 2
+	}
+	return (j*(g.cols+1)+i)*2 + 1
+}
+
+// edgePoint returns the location where the contour crosses an edge, interpolated between the values at each end.
+func (g *grid) edgePoint(id int) *geom.Point {
+	index := id / 2
+	i0 := index % (g.cols + 1)
+	j0 := index / (g.cols + 1)
+	i1, j1 := i0+1, j0
+	if id%2 == 1 {
+		i1, j1 = i0, j0+1
+	}
+	v0 := g.value(i0, j0)
+	v1 := g.value(i1, j1)
+	t := 0.5
+	if v0 != v1 {
+		t = blmath.Clamp((g.level-v0)/(v1-v0), 0, 1)
+	}
+	x0 := g.x + float64(i0)*g.dx
+	y0 := g.y + float64(j0)*g.dy
+	x1 := g.x + float64(i1)*g.dx
+	y1 := g.y + float64(j1)*g.dy
+	return geom.NewPoint(blmath.Lerp(t, x0, x1), blmath.Lerp(t, y0, y1))
+}
+
+// link joins segments that share edges into contours.
+// Chains starting on an edge that no segment ends on are open. Everything left after those is closed.
+func (g *grid) link(segments []segment) (closed, open []geom.PointList) {
+	byStart := map[int]int{}
+	ends := map[int]bool{}
+	for index, s := range segments {
+		byStart[s.start] = index
+		ends[s.end] = true
+	}
+	used := make([]bool, len(segments))
+
+	follow := func(index int) geom.PointList {
+		points := geom.PointList{g.edgePoint(segments[index].start)}
+		for {
+			used[index] = true
+			p := g.edgePoint(segments[index].end)
+			if !p.Equals(points.Last()) {
+				points.Add(p)
+			}
+			next, ok := byStart[segments[index].end]
+			if !ok || used[next] {
+				return points
+			}
+			index = next
+		}
+	}
+
+	for index, s := range segments {
+		if !used[index] && !ends[s.start] {
+			open = append(open, follow(index))
+		}
+	}
+	for index := range segments {
+		if !used[index] {
+			points := follow(index)
+			if len(points) > 1 && points.First().Equals(points.Last()) {
+				points = points[:len(points)-1]
+			}
+			closed = append(closed, points)
+		}
+	}
+	return closed, open
+}
+
+// value returns the sampled value at grid location i, j.
+func (g *grid) value(i, j int) float64 {
+	return g.values[j*(g.cols+1)+i]
+}
+
+// inside reports whether the sampled value at grid location i, j is below the level.
+func (g *grid) inside(i, j int) bool {
+	return g.value(i, j) < g.level
+}
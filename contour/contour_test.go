@@ -0,0 +1,113 @@
+// Package contour extracts iso-contours from scalar fields using marching squares.
+package contour
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/bitmap"
+	"github.com/bit101/bitlib/geom"
+)
+
+func circle(cx, cy, r float64) func(x, y float64) float64 {
+	return func(x, y float64) float64 {
+		return math.Hypot(x-cx, y-cy) - r
+	}
+}
+
+func TestTraceClosed(t *testing.T) {
+	closed, open := Trace(circle(50, 50, 30), geom.NewRect(0, 0, 100, 100), 2, 0)
+	if len(closed) != 1 || len(open) != 0 {
+		t.Fatalf("Expected 1 closed and 0 open, got %d and %d\n", len(closed), len(open))
+	}
+	for _, p := range closed[0] {
+		d := math.Hypot(p.X-50, p.Y-50)
+		if math.Abs(d-30) > 0.1 {
+			t.Errorf("Expected %f, got %f\n", 30.0, d)
+		}
+	}
+	a := closed[0].SignedArea()
+	if math.Abs(a-math.Pi*900) > 10 {
+		t.Errorf("Expected %f, got %f\n", math.Pi*900, a)
+	}
+}
+
+func TestTraceOpen(t *testing.T) {
+	closed, open := Trace(circle(0, 50, 30), geom.NewRect(0, 0, 100, 100), 2, 0)
+	if len(closed) != 0 || len(open) != 1 {
+		t.Fatalf("Expected 0 closed and 1 open, got %d and %d\n", len(closed), len(open))
+	}
+	// inside on the right, so it runs from the top to the bottom on screen.
+	first := open[0].First()
+	last := open[0].Last()
+	if first.X != 0 || math.Abs(first.Y-20) > 0.1 {
+		t.Errorf("Expected (0, 20), got (%f, %f)\n", first.X, first.Y)
+	}
+	if last.X != 0 || math.Abs(last.Y-80) > 0.1 {
+		t.Errorf("Expected (0, 80), got (%f, %f)\n", last.X, last.Y)
+	}
+}
+
+func TestTraceSaddle(t *testing.T) {
+	type test struct {
+		center float64
+		closed int
+	}
+	tests := []test{
+		// low center joins the two inside corners into one area.
+		{-0.5, 1},
+		// high center keeps them apart.
+		{0.5, 2},
+	}
+	for _, test := range tests {
+		// inside at top left and bottom right of a single cell, with the center value set by the other corners.
+		f := func(x, y float64) float64 {
+			if x == y {
+				return -1
+			}
+			return test.center*2 + 1
+		}
+		// wrap the cell in an outside border so every contour is closed.
+		g := func(x, y float64) float64 {
+			if x < 0 || y < 0 || x > 1 || y > 1 {
+				return 1
+			}
+			return f(x, y)
+		}
+		closed, open := Trace(g, geom.NewRect(-1, -1, 3, 3), 1, 0)
+		if len(closed) != test.closed || len(open) != 0 {
+			t.Errorf("Expected %d closed and 0 open, got %d and %d\n", test.closed, len(closed), len(open))
+		}
+	}
+}
+
+func TestTraceBitmap(t *testing.T) {
+	bmp := bitmap.NewBitmap(20, 20)
+	for y := 5; y < 15; y++ {
+		for x := 5; x < 15; x++ {
+			bmp.SetPixelGray(x, y, 1)
+		}
+	}
+	closed, open := TraceBitmap(bmp, bitmap.ChannelGray, 1, 0.5)
+	if len(closed) != 1 || len(open) != 0 {
+		t.Fatalf("Expected 1 closed and 0 open, got %d and %d\n", len(closed), len(open))
+	}
+	// dark is inside, so the bright square is counterclockwise, with its edge halfway between pixel centers.
+	a := closed[0].SignedArea()
+	if a > 0 {
+		t.Errorf("Expected negative area, got %f\n", a)
+	}
+	box := closed[0].BoundingBox()
+	if box.X != 5 || box.Y != 5 || box.W != 10 || box.H != 10 {
+		t.Errorf("Expected 5, 5, 10, 10, got %f, %f, %f, %f\n", box.X, box.Y, box.W, box.H)
+	}
+}
+
+func TestTraceBadRes(t *testing.T) {
+	for _, res := range []float64{0, -1, math.NaN()} {
+		closed, open := Trace(circle(50, 50, 30), geom.NewRect(0, 0, 100, 100), res, 0)
+		if closed != nil || open != nil {
+			t.Errorf("Expected no contours for res %f, got %d and %d\n", res, len(closed), len(open))
+		}
+	}
+}
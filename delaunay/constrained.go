@@ -0,0 +1,573 @@
+// Package delaunay does triangulation
+package delaunay
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// TriangulateConstrained does a Triangulation that includes each of the constraint segments as an edge,
+// and returns a list of triangles. The whole convex hull is filled.
+func TriangulateConstrained(points geom.PointList, constraints geom.SegmentList) geom.TriangleList {
+	return NewConstrainedTriangulation(points, constraints).TriangleList()
+}
+
+// TriangulatePolygon triangulates the inside of a polygon with holes, and returns a list of triangles.
+func TriangulatePolygon(outer geom.PointList, holes []geom.PointList) geom.TriangleList {
+	return NewPolygonTriangulation(outer, holes).TriangleList()
+}
+
+// NewConstrainedTriangulation creates a Triangulation that includes each of the constraint segments as an edge.
+// Points holds the given points, followed by any segment end points that weren't already in the list, and
+// any points where segments cross each other.
+func NewConstrainedTriangulation(points geom.PointList, constraints geom.SegmentList) *Triangulation {
+	all := make(geom.PointList, 0, len(points)+len(constraints)*2)
+	index := map[[2]float64]int{}
+	add := func(p *geom.Point) int {
+		key := [2]float64{p.X, p.Y}
+		if i, ok := index[key]; ok {
+			return i
+		}
+		index[key] = len(all)
+		all.Add(p)
+		return len(all) - 1
+	}
+	for _, p := range points {
+		key := [2]float64{p.X, p.Y}
+		if _, ok := index[key]; !ok {
+			index[key] = len(all)
+		}
+		all.Add(p)
+	}
+	pairs := make([][2]int, len(constraints))
+	for i, s := range constraints {
+		pairs[i] = [2]int{add(s.PointA), add(s.PointB)}
+	}
+
+	t := NewTriangulation(all)
+	for _, pair := range pairs {
+		t.Constrain(pair[0], pair[1])
+	}
+	return t
+}
+
+// NewPolygonTriangulation creates a Triangulation of the inside of a polygon with holes.
+// Points holds the outer points followed by the points of each hole.
+func NewPolygonTriangulation(outer geom.PointList, holes []geom.PointList) *Triangulation {
+	points := geom.NewPointList()
+	constraints := geom.NewSegmentList()
+	for _, polygon := range append([]geom.PointList{outer}, holes...) {
+		for i, p := range polygon {
+			q := polygon[(i+1)%len(polygon)]
+			points.Add(p)
+			constraints.Add(geom.NewSegmentFromPoints(p, q))
+		}
+	}
+	t := NewConstrainedTriangulation(points, constraints)
+	t.RemoveOutside()
+	return t
+}
+
+// Constrain makes sure there is an edge between the points at indices a and b.
+// Edges that cross it are flipped out of the way. If it passes through another point, it is split there.
+// If it crosses an earlier constraint, a point is added where they cross.
+// Constrained edges are never flipped by Constrain or Refine.
+func (t *Triangulation) Constrain(a, b int) {
+	t.index()
+	t.constrain(a, b)
+}
+
+// RemoveOutside removes the triangles that are outside the constrained edges, using the even-odd rule.
+// Starting from the outside, each constrained edge crossed switches between outside and inside,
+// so holes are removed as well.
+func (t *Triangulation) RemoveOutside() {
+	count := len(t.Triangles) / 3
+	depth := make([]int, count)
+	for i := range depth {
+		depth[i] = -1
+	}
+
+	// a search that visits triangles in order of depth, with a bucket of triangles for each depth.
+	// crossing a constrained edge goes one deeper, and crossing any other edge stays at the same depth.
+	buckets := [][]int{}
+	push := func(tri, d int) {
+		if depth[tri] != -1 && depth[tri] <= d {
+			return
+		}
+		depth[tri] = d
+		for len(buckets) <= d {
+			buckets = append(buckets, nil)
+		}
+		buckets[d] = append(buckets[d], tri)
+	}
+	for e, o := range t.Halfedges {
+		if o == -1 {
+			if t.isConstrained(e) {
+				push(e/3, 1)
+			} else {
+				push(e/3, 0)
+			}
+		}
+	}
+	for d := 0; d < len(buckets); d++ {
+		for i := 0; i < len(buckets[d]); i++ {
+			tri := buckets[d][i]
+			if depth[tri] != d {
+				continue
+			}
+			for e := tri * 3; e < tri*3+3; e++ {
+				if o := t.Halfedges[e]; o != -1 {
+					if t.isConstrained(e) {
+						push(o/3, d+1)
+					} else {
+						push(o/3, d)
+					}
+				}
+			}
+		}
+	}
+
+	newIndex := make([]int, count)
+	kept := 0
+	for i, d := range depth {
+		newIndex[i] = -1
+		if d%2 == 1 {
+			newIndex[i] = kept
+			kept++
+		}
+	}
+	triangles := make([]int, 0, kept*3)
+	halfedges := make([]int, 0, kept*3)
+	for e, o := range t.Halfedges {
+		if newIndex[e/3] == -1 {
+			continue
+		}
+		triangles = append(triangles, t.Triangles[e])
+		if o == -1 || newIndex[o/3] == -1 {
+			halfedges = append(halfedges, -1)
+		} else {
+			halfedges = append(halfedges, newIndex[o/3]*3+o%3)
+		}
+	}
+	t.Triangles = triangles
+	t.Halfedges = halfedges
+	t.inedge = nil
+}
+
+//////////////////////////////
+// Constraints
+//////////////////////////////
+
+// constrain adds a constraint between points a and b, splitting it at any points or constraints in the way.
+func (t *Triangulation) constrain(a, b int) {
+	if a == b || t.inedge[a] == -1 || t.inedge[b] == -1 {
+		// the same point, or a duplicate that was left out of the triangulation.
+		return
+	}
+	if t.findEdge(a, b) != -1 || t.findEdge(b, a) != -1 {
+		t.constraints[edgeKey(a, b)] = true
+		return
+	}
+
+	// find the edge in the fan around a that the constraint crosses first.
+	e := -1
+	for _, p := range t.neighbors(a) {
+		if t.onSegment(p, a, b) {
+			t.constrain(a, p)
+			t.constrain(p, b)
+			return
+		}
+	}
+	for _, out := range t.around(a) {
+		p := t.Triangles[NextHalfedge(out)]
+		q := t.Triangles[PrevHalfedge(out)]
+		if t.cross(a, b, p)*t.cross(a, b, q) < 0 && t.cross(p, q, a)*t.cross(p, q, b) < 0 {
+			e = NextHalfedge(out)
+			break
+		}
+	}
+	if e == -1 {
+		// rounding can hide where the constraint leaves the fan when it passes very close to a point.
+		t.constrainHalves(a, b)
+		return
+	}
+
+	// walk through the triangles the constraint crosses, collecting the edges to get rid of.
+	crossed := [][2]int{}
+	for {
+		p := t.Triangles[e]
+		q := t.Triangles[NextHalfedge(e)]
+		if t.isConstrained(e) {
+			// split both constraints where they cross.
+			x, y := t.intersection(a, b, p, q)
+			m := t.insertOnEdge(e, x, y)
+			t.constrain(a, m)
+			t.constrain(m, b)
+			return
+		}
+		crossed = append(crossed, [2]int{p, q})
+		o := t.Halfedges[e]
+		if o == -1 {
+			// rounding can also make the walk miss b and run out of the triangles.
+			t.constrainHalves(a, b)
+			return
+		}
+		r := t.Triangles[PrevHalfedge(o)]
+		if r == b {
+			break
+		}
+		if t.onSegment(r, a, b) {
+			t.constrain(a, r)
+			t.constrain(r, b)
+			return
+		}
+		if t.cross(a, b, r)*t.cross(a, b, p) > 0 {
+			e = PrevHalfedge(o)
+		} else {
+			e = NextHalfedge(o)
+		}
+	}
+
+	// flip crossed edges until none are left, as in Sloan's algorithm. an edge that can't be flipped
+	// yet because its triangles make a concave shape goes to the back of the queue.
+	// stuck counts the edges tried since the last flip, so a pass with nothing flipped ends the loop.
+	added := [][2]int{}
+	stuck := 0
+	for len(crossed) > 0 && stuck < len(crossed) {
+		pair := crossed[0]
+		crossed = crossed[1:]
+		e := t.findEdge(pair[0], pair[1])
+		if e == -1 {
+			continue
+		}
+		o := t.Halfedges[e]
+		if o == -1 {
+			crossed = append(crossed, pair)
+			stuck++
+			continue
+		}
+		p0 := t.Triangles[PrevHalfedge(e)]
+		p1 := t.Triangles[PrevHalfedge(o)]
+		if t.cross(p0, p1, pair[0])*t.cross(p0, p1, pair[1]) >= 0 {
+			crossed = append(crossed, pair)
+			stuck++
+			continue
+		}
+		stuck = 0
+		t.flip(e)
+		diagonal := [2]int{p0, p1}
+		if t.cross(a, b, p0)*t.cross(a, b, p1) < 0 && t.cross(p0, p1, a)*t.cross(p0, p1, b) < 0 {
+			crossed = append(crossed, diagonal)
+		} else {
+			added = append(added, diagonal)
+		}
+	}
+
+	// the new edges might not be Delaunay.
+	stack := []int{}
+	for _, pair := range added {
+		if e := t.findEdge(pair[0], pair[1]); e != -1 {
+			stack = append(stack, e)
+		}
+	}
+	if len(crossed) > 0 {
+		// rounding left edges that none of the flips could get rid of.
+		t.restore(stack)
+		t.constrainHalves(a, b)
+		return
+	}
+	t.constraints[edgeKey(a, b)] = true
+	t.restore(stack)
+}
+
+// constrainHalves splits the constraint between points a and b in the middle, and adds the two halves.
+// They are shorter and leave a and b at different angles, which gets around most rounding problems.
+// If the constraint can't be split, it is left out.
+func (t *Triangulation) constrainHalves(a, b int) {
+	if m := t.insertMidpoint(a, b); m != -1 {
+		t.constrain(a, m)
+		t.constrain(m, b)
+	}
+}
+
+// isConstrained reports whether edge e is constrained.
+func (t *Triangulation) isConstrained(e int) bool {
+	return t.constraints[edgeKey(t.Triangles[e], t.Triangles[NextHalfedge(e)])]
+}
+
+// edgeKey returns the key for the edge between two points, whichever way round they are.
+func edgeKey(a, b int) [2]int {
+	return [2]int{min(a, b), max(a, b)}
+}
+
+//////////////////////////////
+// Editing
+//////////////////////////////
+
+// index sets up the lookups needed for editing the triangulation, if they aren't already.
+func (t *Triangulation) index() {
+	if t.constraints == nil {
+		t.constraints = map[[2]int]bool{}
+	}
+	if t.inedge != nil {
+		return
+	}
+	t.inedge = make([]int, len(t.Points))
+	for i := range t.inedge {
+		t.inedge[i] = -1
+	}
+	for i := 0; i < len(t.Triangles); i += 3 {
+		t.touch(i)
+	}
+}
+
+// touch records an edge coming in to each point of a triangle.
+func (t *Triangulation) touch(tri int) {
+	tri -= tri % 3
+	for e := tri; e < tri+3; e++ {
+		t.inedge[t.Triangles[NextHalfedge(e)]] = e
+	}
+}
+
+// around returns the edges leading out of point p.
+func (t *Triangulation) around(p int) []int {
+	start := t.inedge[p]
+	if start == -1 {
+		return nil
+	}
+	// go back as far as the outside, or all the way round.
+	e := start
+	for t.Halfedges[e] != -1 {
+		e = PrevHalfedge(t.Halfedges[e])
+		if e == start {
+			break
+		}
+	}
+	edges := []int{}
+	in := e
+	for {
+		out := NextHalfedge(in)
+		edges = append(edges, out)
+		in = t.Halfedges[out]
+		if in == -1 || in == e {
+			break
+		}
+	}
+	return edges
+}
+
+// neighbors returns the points joined to point p by an edge.
+func (t *Triangulation) neighbors(p int) []int {
+	edges := t.around(p)
+	points := make([]int, 0, len(edges)+1)
+	for _, out := range edges {
+		points = append(points, t.Triangles[NextHalfedge(out)])
+	}
+	// on the outside, the first edge into p comes from a point that no edge out of p leads to.
+	if len(edges) > 0 {
+		if in := PrevHalfedge(edges[0]); t.Halfedges[in] == -1 {
+			points = append(points, t.Triangles[in])
+		}
+	}
+	return points
+}
+
+// findEdge returns the edge from point a to point b, or -1 if there isn't one.
+func (t *Triangulation) findEdge(a, b int) int {
+	for _, out := range t.around(a) {
+		if t.Triangles[NextHalfedge(out)] == b {
+			return out
+		}
+	}
+	return -1
+}
+
+// flip replaces edge a and its opposite with the other diagonal of the two triangles.
+// Afterwards, the new diagonal is at PrevHalfedge(a).
+func (t *Triangulation) flip(a int) {
+	b := t.Halfedges[a]
+	ar := PrevHalfedge(a)
+	bl := PrevHalfedge(b)
+	p0 := t.Triangles[ar]
+	p1 := t.Triangles[bl]
+	hbl := t.Halfedges[bl]
+	har := t.Halfedges[ar]
+	t.Triangles[a] = p1
+	t.Triangles[b] = p0
+	t.link(a, hbl)
+	t.link(b, har)
+	t.link(ar, bl)
+	t.touch(a)
+	t.touch(b)
+}
+
+// restore flips edges from the stack, and the edges around them, until they are all Delaunay.
+// Constrained edges are left alone.
+func (t *Triangulation) restore(stack []int) {
+	for len(stack) > 0 {
+		a := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		b := t.Halfedges[a]
+		if b == -1 || t.isConstrained(a) {
+			continue
+		}
+		p0 := t.Triangles[PrevHalfedge(a)]
+		pr := t.Triangles[a]
+		pl := t.Triangles[NextHalfedge(a)]
+		p1 := t.Triangles[PrevHalfedge(b)]
+		c := t.coords
+		if !inCircle(c[p0*2], c[p0*2+1], c[pr*2], c[pr*2+1], c[pl*2], c[pl*2+1], c[p1*2], c[p1*2+1]) {
+			continue
+		}
+		t.flip(a)
+		stack = append(stack, a, NextHalfedge(a), b, NextHalfedge(b))
+	}
+}
+
+// addPoint adds a point to the end of Points, returning its index.
+func (t *Triangulation) addPoint(x, y float64) int {
+	t.Points.AddXY(x, y)
+	t.coords = append(t.coords, x, y)
+	t.inedge = append(t.inedge, -1)
+	return len(t.Points) - 1
+}
+
+// insertInTriangle adds a point inside a triangle, splitting it in three, and returns the point's index.
+func (t *Triangulation) insertInTriangle(tri int, x, y float64) int {
+	tri -= tri % 3
+	p := t.addPoint(x, y)
+	a := t.Triangles[tri]
+	b := t.Triangles[tri+1]
+	c := t.Triangles[tri+2]
+	hbc := t.Halfedges[tri+1]
+	hca := t.Halfedges[tri+2]
+
+	t.Triangles[tri+2] = p
+	t.link(tri+1, -1)
+	t.link(tri+2, -1)
+	t1 := t.addTriangle(b, c, p, hbc, -1, tri+1)
+	t2 := t.addTriangle(c, a, p, hca, tri+2, t1+1)
+	t.touch(tri)
+	t.touch(t1)
+	t.touch(t2)
+	t.restore([]int{tri, t1, t2})
+	return p
+}
+
+// insertOnEdge adds a point on edge e, splitting the triangles on both sides in two, and returns the point's index.
+// If the edge is constrained, both halves are.
+func (t *Triangulation) insertOnEdge(e int, x, y float64) int {
+	p := t.addPoint(x, y)
+	o := t.Halfedges[e]
+	u := t.Triangles[e]
+	v := t.Triangles[NextHalfedge(e)]
+	w := t.Triangles[PrevHalfedge(e)]
+	constrained := t.isConstrained(e)
+
+	// u, v, w becomes u, p, w and p, v, w.
+	hvw := t.Halfedges[NextHalfedge(e)]
+	t.Triangles[NextHalfedge(e)] = p
+	a2 := t.addTriangle(p, v, w, -1, hvw, NextHalfedge(e))
+	t.touch(e)
+	t.touch(a2)
+	stack := []int{PrevHalfedge(e), a2 + 1}
+
+	if o == -1 {
+		t.link(e, -1)
+	} else {
+		// v, u, z becomes v, p, z and p, u, z.
+		z := t.Triangles[PrevHalfedge(o)]
+		huz := t.Halfedges[NextHalfedge(o)]
+		t.Triangles[NextHalfedge(o)] = p
+		b2 := t.addTriangle(p, u, z, e, huz, NextHalfedge(o))
+		t.link(o, a2)
+		t.touch(o)
+		t.touch(b2)
+		stack = append(stack, PrevHalfedge(o), b2+1)
+	}
+
+	if constrained {
+		delete(t.constraints, edgeKey(u, v))
+		t.constraints[edgeKey(u, p)] = true
+		t.constraints[edgeKey(p, v)] = true
+	}
+	t.restore(stack)
+	return p
+}
+
+// insertMidpoint adds a point halfway between points a and b, and returns its index. If there is already a point
+// there, that is returned instead. It returns -1 if the halfway point is outside the triangles, or can't be told
+// apart from a or b.
+func (t *Triangulation) insertMidpoint(a, b int) int {
+	c := t.coords
+	x := (c[a*2] + c[b*2]) / 2
+	y := (c[a*2+1] + c[b*2+1]) / 2
+	if (x == c[a*2] && y == c[a*2+1]) || (x == c[b*2] && y == c[b*2+1]) {
+		return -1
+	}
+	for tri := 0; tri < len(t.Triangles); tri += 3 {
+		edge := -1
+		inside := true
+		for e := tri; e < tri+3 && inside; e++ {
+			p := t.Triangles[e]
+			q := t.Triangles[NextHalfedge(e)]
+			if x == c[p*2] && y == c[p*2+1] {
+				return p
+			}
+			side := crossXY(c[p*2], c[p*2+1], c[q*2], c[q*2+1], x, y) * t.cross(p, q, t.Triangles[PrevHalfedge(e)])
+			if side == 0 {
+				edge = e
+			}
+			inside = side >= 0
+		}
+		if !inside {
+			continue
+		}
+		if edge != -1 {
+			return t.insertOnEdge(edge, x, y)
+		}
+		return t.insertInTriangle(tri, x, y)
+	}
+	return -1
+}
+
+//////////////////////////////
+// Geometry
+//////////////////////////////
+
+// cross returns the cross product of b - a and p - a, for point indices.
+// It is positive on one side of the line through a and b, and negative on the other.
+func (t *Triangulation) cross(a, b, p int) float64 {
+	c := t.coords
+	return crossXY(c[a*2], c[a*2+1], c[b*2], c[b*2+1], c[p*2], c[p*2+1])
+}
+
+// crossXY returns the cross product of b - a and p - a.
+func crossXY(ax, ay, bx, by, px, py float64) float64 {
+	return (bx-ax)*(py-ay) - (by-ay)*(px-ax)
+}
+
+// onSegment reports whether point p is on the segment between points a and b, not counting the ends.
+func (t *Triangulation) onSegment(p, a, b int) bool {
+	if p == a || p == b {
+		return false
+	}
+	c := t.coords
+	dx := c[b*2] - c[a*2]
+	dy := c[b*2+1] - c[a*2+1]
+	lengthSq := dx*dx + dy*dy
+	if math.Abs(t.cross(a, b, p)) > lengthSq*1e-12 {
+		return false
+	}
+	dot := (c[p*2]-c[a*2])*dx + (c[p*2+1]-c[a*2+1])*dy
+	return dot > 0 && dot < lengthSq
+}
+
+// intersection returns where the line through points a and b crosses the line through points p and q.
+func (t *Triangulation) intersection(a, b, p, q int) (float64, float64) {
+	c := t.coords
+	d := t.cross(p, q, a) - t.cross(p, q, b)
+	s := t.cross(p, q, a) / d
+	return c[a*2] + (c[b*2]-c[a*2])*s, c[a*2+1] + (c[b*2+1]-c[a*2+1])*s
+}
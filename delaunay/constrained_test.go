@@ -0,0 +1,219 @@
+// Package delaunay does triangulation
+package delaunay
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+	"github.com/bit101/bitlib/geom"
+)
+
+// triangleArea returns the total area of the triangles in a triangulation.
+func triangleArea(tri *Triangulation) float64 {
+	area := 0.0
+	for i := 0; i < len(tri.Triangles); i += 3 {
+		area += math.Abs(tri.cross(tri.Triangles[i], tri.Triangles[i+1], tri.Triangles[i+2])) / 2
+	}
+	return area
+}
+
+// hasEdge reports whether there is an edge between the points at x0, y0 and x1, y1.
+func hasEdge(tri *Triangulation, x0, y0, x1, y1 float64) bool {
+	for e := range tri.Triangles {
+		p := tri.Points[tri.Triangles[e]]
+		q := tri.Points[tri.Triangles[NextHalfedge(e)]]
+		if (p.X == x0 && p.Y == y0 && q.X == x1 && q.Y == y1) || (p.X == x1 && p.Y == y1 && q.X == x0 && q.Y == y0) {
+			return true
+		}
+	}
+	return false
+}
+
+// ring returns a closed list of points from x, y pairs.
+func ring(coords ...float64) geom.PointList {
+	points := geom.NewPointList()
+	for i := 0; i < len(coords); i += 2 {
+		points.AddXY(coords[i], coords[i+1])
+	}
+	return points
+}
+
+// ringSegments returns the segments around a ring.
+func ringSegments(points geom.PointList) geom.SegmentList {
+	segments := geom.NewSegmentList()
+	for i, p := range points {
+		segments.Add(geom.NewSegmentFromPoints(p, points[(i+1)%len(points)]))
+	}
+	return segments
+}
+
+func TestConstrainThroughHullPoint(t *testing.T) {
+	// the bottom side passes through a point on the hull. it has to be split there, whichever way it goes.
+	for _, reverse := range []bool{false, true} {
+		points := ring(0, 0, 5, 0, 10, 0, 10, 10, 0, 10, 4, 6)
+		square := ring(0, 0, 10, 0, 10, 10, 0, 10)
+		if reverse {
+			square = ring(0, 10, 10, 10, 10, 0, 0, 0)
+		}
+		tri := NewConstrainedTriangulation(points, ringSegments(square))
+		checkTriangulation(t, tri)
+		tri.RemoveOutside()
+		checkTriangulation(t, tri)
+		if !blmath.Equalish(triangleArea(tri), 100, 1e-9) {
+			t.Errorf("Expected %f, got %f\n", 100.0, triangleArea(tri))
+		}
+	}
+}
+
+func TestTriangulateConstrained(t *testing.T) {
+	points := randomPoints(6, 100, geom.NewRect(0, 0, 100, 100))
+	constraints := geom.NewSegmentList()
+	// two constraints that cross each other, and one running through a grid of points.
+	constraints.Add(geom.NewSegment(5, 5, 95, 90))
+	constraints.Add(geom.NewSegment(10, 80, 90, 20))
+	for x := 20.0; x <= 80; x += 10 {
+		points.AddXY(x, 95)
+	}
+	constraints.Add(geom.NewSegment(20, 95, 80, 95))
+	tri := NewConstrainedTriangulation(points, constraints)
+	checkTriangulation(t, tri)
+	for x := 20.0; x < 80; x += 10 {
+		if !hasEdge(tri, x, 95, x+10, 95) {
+			t.Errorf("Expected an edge from %f, 95 to %f, 95\n", x, x+10)
+		}
+	}
+	// the crossing constraints get a new point where they cross, which is at the end of Points.
+	if len(tri.Points) < len(points)+1 {
+		t.Errorf("Expected a point to be added where the constraints cross\n")
+	}
+	// the whole hull is still filled.
+	hull := geom.NewPointList()
+	for _, i := range tri.Hull {
+		hull.Add(tri.Points[i])
+	}
+	if !blmath.Equalish(triangleArea(tri), math.Abs(hull.SignedArea()), 1e-6) {
+		t.Errorf("Expected %f, got %f\n", math.Abs(hull.SignedArea()), triangleArea(tri))
+	}
+	if len(TriangulateConstrained(points, constraints)) != len(tri.Triangles)/3 {
+		t.Errorf("Expected %d, got %d\n", len(tri.Triangles)/3, len(TriangulateConstrained(points, constraints)))
+	}
+}
+
+func TestTriangulatePolygon(t *testing.T) {
+	// a concave outline with a square hole.
+	outer := ring(0, 0, 100, 0, 100, 100, 60, 100, 50, 40, 40, 100, 0, 100)
+	hole := ring(10, 10, 30, 10, 30, 30, 10, 30)
+	tri := NewPolygonTriangulation(outer, []geom.PointList{hole})
+	checkTriangulation(t, tri)
+	expected := math.Abs(outer.SignedArea()) - math.Abs(hole.SignedArea())
+	if !blmath.Equalish(triangleArea(tri), expected, 1e-9) {
+		t.Errorf("Expected %f, got %f\n", expected, triangleArea(tri))
+	}
+	// nothing is inside the hole.
+	for _, triangle := range TriangulatePolygon(outer, []geom.PointList{hole}) {
+		center := geom.NewPoint((triangle.PointA.X+triangle.PointB.X+triangle.PointC.X)/3, (triangle.PointA.Y+triangle.PointB.Y+triangle.PointC.Y)/3)
+		if center.X > 10 && center.X < 30 && center.Y > 10 && center.Y < 30 {
+			t.Errorf("Expected no triangles in the hole, got one centered at %f, %f\n", center.X, center.Y)
+		}
+	}
+}
+
+func TestConstrainCopiesPoints(t *testing.T) {
+	// spare capacity in the caller's list must not be written to when points are added.
+	points := make(geom.PointList, 0, 20)
+	points = append(points, ring(0, 0, 10, 0, 10, 10, 0, 10)...)
+	spare := points[:cap(points)]
+	tri := NewTriangulation(points)
+	tri.Constrain(0, 2)
+	tri.Constrain(1, 3)
+	if len(tri.Points) != 5 {
+		t.Errorf("Expected %d, got %d\n", 5, len(tri.Points))
+	}
+	tri.Refine(0.4, 1)
+	for _, p := range spare[len(points):] {
+		if p != nil {
+			t.Errorf("Expected the caller's points to be left alone\n")
+			break
+		}
+	}
+}
+
+func TestRemoveOutside(t *testing.T) {
+	points := randomPoints(7, 50, geom.NewRect(0, 0, 100, 100))
+	square := ring(20, 20, 80, 20, 80, 80, 20, 80)
+	tri := NewConstrainedTriangulation(points, ringSegments(square))
+	tri.RemoveOutside()
+	checkTriangulation(t, tri)
+	if !blmath.Equalish(triangleArea(tri), 3600, 1e-9) {
+		t.Errorf("Expected %f, got %f\n", 3600.0, triangleArea(tri))
+	}
+}
+
+func TestRefine(t *testing.T) {
+	outer := ring(0, 0, 100, 0, 100, 100, 0, 100)
+	hole := ring(40, 40, 60, 40, 60, 60, 40, 60)
+	tri := NewPolygonTriangulation(outer, []geom.PointList{hole})
+	minAngle := 25 * math.Pi / 180
+	maxArea := 50.0
+	if !tri.Refine(minAngle, maxArea) {
+		t.Errorf("Expected Refine to finish\n")
+	}
+	checkTriangulation(t, tri)
+	if !blmath.Equalish(triangleArea(tri), 9600, 1e-6) {
+		t.Errorf("Expected %f, got %f\n", 9600.0, triangleArea(tri))
+	}
+	for i := 0; i < len(tri.Triangles); i += 3 {
+		a, b, c := tri.Points[tri.Triangles[i]], tri.Points[tri.Triangles[i+1]], tri.Points[tri.Triangles[i+2]]
+		area := math.Abs(geom.PointList{a, b, c}.SignedArea())
+		if area > maxArea*(1+1e-9) {
+			t.Errorf("Expected an area of at most %f, got %f\n", maxArea, area)
+		}
+		for _, corner := range [][3]*geom.Point{{a, b, c}, {b, c, a}, {c, a, b}} {
+			u := geom.VectorBetween(corner[0].X, corner[0].Y, corner[1].X, corner[1].Y)
+			v := geom.VectorBetween(corner[0].X, corner[0].Y, corner[2].X, corner[2].Y)
+			angle := math.Acos(blmath.Clamp(u.DotProduct(v)/(u.Magnitude()*v.Magnitude()), -1, 1))
+			if angle < minAngle-1e-9 {
+				t.Errorf("Expected angles of at least %f, got %f\n", minAngle, angle)
+			}
+		}
+	}
+}
+
+func TestRefineLargeAngle(t *testing.T) {
+	outer := ring(0, 0, 100, 0, 100, 100, 0, 100)
+	hole := ring(40, 40, 60, 40, 60, 60, 40, 60)
+	// angles this large can't be reached, so they are reduced to one that can.
+	for _, minAngle := range []float64{0.65, 1, math.Pi} {
+		for _, maxArea := range []float64{0, 10} {
+			tri := NewPolygonTriangulation(outer, []geom.PointList{hole})
+			if !tri.Refine(minAngle, maxArea) {
+				t.Errorf("Expected Refine to finish for %f, %f\n", minAngle, maxArea)
+			}
+			checkTriangulation(t, tri)
+			if !blmath.Equalish(triangleArea(tri), 9600, 1e-6) {
+				t.Errorf("Expected %f, got %f\n", 9600.0, triangleArea(tri))
+			}
+		}
+	}
+}
+
+func TestConstrainNearlyCollinear(t *testing.T) {
+	// points a hair off a line, with constraints along and across it, are where rounding causes trouble.
+	points := geom.NewPointList()
+	for i := 0; i <= 40; i++ {
+		x := float64(i) * 2.5
+		points.AddXY(x, 50+math.Sin(x*7)*1e-9)
+	}
+	points.AddXY(50, 0)
+	points.AddXY(50, 100)
+	constraints := geom.NewSegmentList()
+	constraints.Add(geom.NewSegment(0, 50, 100, 50+1e-9))
+	constraints.Add(geom.NewSegment(1.25, 50-1e-10, 98.75, 50+1e-10))
+	constraints.Add(geom.NewSegment(50, 0, 50, 100))
+	constraints.Add(geom.NewSegment(0, 50, 50, 100))
+	tri := NewConstrainedTriangulation(points, constraints)
+	checkTriangulation(t, tri)
+	tri.RemoveOutside()
+	checkTriangulation(t, tri)
+}
@@ -0,0 +1,372 @@
+// Package delaunay does triangulation
+package delaunay
+
+import (
+	"math"
+)
+
+// results of locating a point.
+const (
+	locateInside = iota
+	locateEdge
+	locateBlocked
+	locateFailed
+)
+
+// maxMinAngle is the largest minimum angle Refine will aim for, about 31 degrees.
+// Past this, the added points can keep making new small angles and refining never ends.
+const maxMinAngle = 0.55
+
+// refineLimit is the most points Refine will add for each input point, on top of those needed to meet maxArea.
+const refineLimit = 100
+
+// refiner holds the state of a Refine.
+type refiner struct {
+	t *Triangulation
+	// count is the number of points before refining. Points before this are input points.
+	count int
+	// origin holds, for each point added on a segment, the input points at the ends of the original segment.
+	origin map[int][2]int
+	// minLength is the shortest segment that will be split, to stop rounding errors running away.
+	minLength float64
+	// triangles and segments are waiting to be checked.
+	triangles []int
+	segments  [][2]int
+}
+
+// Refine adds points to improve the shape and size of the triangles, using Ruppert's algorithm.
+// Points are added until no triangle has an angle smaller than minAngle, in radians, or an area larger than maxArea.
+// A maxArea of 0 means there is no area limit. Angles up to about 0.5 radians (28 degrees) work reliably,
+// and larger angles are reduced to 0.55 radians (31 degrees).
+// Constrained edges and outside edges are split where needed, and the parts of a constrained edge are
+// constrained too. Small angles between two constrained or outside edges can't be improved, so they are left alone.
+// To make sure it finishes, Refine stops after adding 100 points for each point it started with, plus enough
+// to meet maxArea several times over. It returns false if it stopped there, leaving some triangles unrefined.
+func (t *Triangulation) Refine(minAngle, maxArea float64) bool {
+	t.index()
+	minAngle = math.Min(minAngle, maxMinAngle)
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range t.Points {
+		minX = math.Min(minX, p.X)
+		minY = math.Min(minY, p.Y)
+		maxX = math.Max(maxX, p.X)
+		maxY = math.Max(maxY, p.Y)
+	}
+	r := &refiner{
+		t:         t,
+		count:     len(t.Points),
+		origin:    map[int][2]int{},
+		minLength: math.Hypot(maxX-minX, maxY-minY) * 1e-9,
+	}
+	for e, o := range t.Halfedges {
+		if t.isSegment(e) && e > o {
+			r.segments = append(r.segments, [2]int{t.Triangles[e], t.Triangles[NextHalfedge(e)]})
+		}
+	}
+	area := 0.0
+	for i := 0; i < len(t.Triangles); i += 3 {
+		r.triangles = append(r.triangles, i)
+		area += math.Abs(t.cross(t.Triangles[i], t.Triangles[i+1], t.Triangles[i+2])) / 2
+	}
+	limit := float64(refineLimit * r.count)
+	if maxArea > 0 {
+		// well shaped triangles no larger than maxArea average at least a quarter of it.
+		limit += area / maxArea * 8
+	}
+	limit = math.Min(limit, math.MaxInt32)
+
+	for {
+		if float64(len(t.Points)-r.count) >= limit {
+			return false
+		}
+		// encroached segments are split before anything else.
+		if len(r.segments) > 0 {
+			pair := r.segments[len(r.segments)-1]
+			r.segments = r.segments[:len(r.segments)-1]
+			e := t.edgeBetween(pair[0], pair[1])
+			if e != -1 && t.isSegment(e) && t.encroached(e) {
+				r.split(e)
+			}
+			continue
+		}
+		if len(r.triangles) == 0 {
+			return true
+		}
+		tri := r.triangles[len(r.triangles)-1]
+		r.triangles = r.triangles[:len(r.triangles)-1]
+		if !r.isBad(tri, minAngle, maxArea) {
+			continue
+		}
+
+		// try adding the circumcenter. if it is on the far side of a segment, or is too close to one, the segment
+		// is split instead and the triangle is tried again later.
+		c := t.coords
+		a, b, d := t.Triangles[tri], t.Triangles[tri+1], t.Triangles[tri+2]
+		x, y := circumcenter(c[a*2], c[a*2+1], c[b*2], c[b*2+1], c[d*2], c[d*2+1])
+		e, result := t.locate(tri, x, y)
+		if result == locateFailed {
+			continue
+		}
+		if result == locateBlocked {
+			// a segment too far from the circumcenter to be encroached by it can't be helped by splitting.
+			if t.encroachedAt(e, x, y) && r.split(e) {
+				r.triangles = append(r.triangles, tri)
+			}
+			continue
+		}
+		if encroached := t.encroachedBy(e, x, y); len(encroached) > 0 {
+			split := false
+			for _, pair := range encroached {
+				if e := t.edgeBetween(pair[0], pair[1]); e != -1 && r.split(e) {
+					split = true
+				}
+			}
+			if split {
+				r.triangles = append(r.triangles, tri)
+			}
+			continue
+		}
+		if result == locateEdge {
+			r.inserted(t.insertOnEdge(e, x, y))
+		} else {
+			r.inserted(t.insertInTriangle(e, x, y))
+		}
+	}
+}
+
+// inserted queues the triangles around a new point to be checked, and the segments that it might encroach.
+func (r *refiner) inserted(p int) {
+	t := r.t
+	for _, out := range t.around(p) {
+		r.triangles = append(r.triangles, out-out%3)
+		for _, e := range []int{out, NextHalfedge(out)} {
+			if t.isSegment(e) {
+				r.segments = append(r.segments, [2]int{t.Triangles[e], t.Triangles[NextHalfedge(e)]})
+			}
+		}
+	}
+}
+
+// split adds a point near the middle of segment e, returning false if the segment is too short to split.
+// If just one end is an input point, the split is at a power of two distance from that end. Splits near a corner
+// between segments then happen at the same distances on each, making rings of points around the corner
+// rather than splitting each other forever.
+func (r *refiner) split(e int) bool {
+	t := r.t
+	length := t.length(e)
+	if length < r.minLength {
+		return false
+	}
+	u := t.Triangles[e]
+	v := t.Triangles[NextHalfedge(e)]
+	s := 0.5
+	if (u < r.count) != (v < r.count) {
+		s = math.Pow(2, math.Round(math.Log2(length/2))) / length
+		if v < r.count {
+			s = 1 - s
+		}
+	}
+	origin := [2]int{u, v}
+	if o, ok := r.origin[u]; ok {
+		origin = o
+	} else if o, ok := r.origin[v]; ok {
+		origin = o
+	}
+	c := t.coords
+	p := t.insertOnEdge(e, c[u*2]+(c[v*2]-c[u*2])*s, c[u*2+1]+(c[v*2+1]-c[u*2+1])*s)
+	r.origin[p] = origin
+	r.inserted(p)
+	return true
+}
+
+// isBad reports whether a triangle has an angle smaller than minAngle or an area larger than maxArea.
+func (r *refiner) isBad(tri int, minAngle, maxArea float64) bool {
+	t := r.t
+	area := math.Abs(t.cross(t.Triangles[tri], t.Triangles[tri+1], t.Triangles[tri+2])) / 2
+	if area == 0 {
+		return false
+	}
+	if maxArea > 0 && area > maxArea {
+		return true
+	}
+
+	// the smallest angle is opposite the shortest edge.
+	lengths := [3]float64{}
+	for i := 0; i < 3; i++ {
+		lengths[i] = t.length(tri + i)
+	}
+	shortest := 0
+	for i := 1; i < 3; i++ {
+		if lengths[i] < lengths[shortest] {
+			shortest = i
+		}
+	}
+	// the edges after the shortest one meet at the corner opposite it.
+	e0 := tri + (shortest+1)%3
+	e1 := tri + (shortest+2)%3
+	sin := 2 * area / (lengths[e0-tri] * lengths[e1-tri])
+	if math.Asin(math.Min(sin, 1)) >= minAngle {
+		return false
+	}
+	if t.isSegment(e0) && t.isSegment(e1) {
+		return false
+	}
+	return !r.acrossCorner(t.Triangles[tri+shortest], t.Triangles[e0])
+}
+
+// acrossCorner reports whether two points are on different segments that meet at a small angle, at the same
+// distance from the corner. Splitting a triangle between them would just lead to more splits closer to the corner.
+func (r *refiner) acrossCorner(p, q int) bool {
+	a, okA := r.origin[p]
+	b, okB := r.origin[q]
+	if !okA || !okB || a == b {
+		return false
+	}
+	corner := -1
+	for _, i := range a {
+		if i == b[0] || i == b[1] {
+			corner = i
+		}
+	}
+	if corner == -1 {
+		return false
+	}
+	c := r.t.coords
+	dp := math.Sqrt(dist(c[p*2], c[p*2+1], c[corner*2], c[corner*2+1]))
+	dq := math.Sqrt(dist(c[q*2], c[q*2+1], c[corner*2], c[corner*2+1]))
+	return math.Abs(dp-dq) < math.Max(dp, dq)*1e-6
+}
+
+// isSegment reports whether edge e is constrained or on the outside, so it can only be split, not flipped.
+func (t *Triangulation) isSegment(e int) bool {
+	return t.Halfedges[e] == -1 || t.isConstrained(e)
+}
+
+// edgeBetween returns an edge between points a and b, going either way, or -1 if there isn't one.
+func (t *Triangulation) edgeBetween(a, b int) int {
+	if e := t.findEdge(a, b); e != -1 {
+		return e
+	}
+	return t.findEdge(b, a)
+}
+
+// encroached reports whether the point opposite edge e in either of its triangles is inside the circle
+// that has the edge as its diameter.
+func (t *Triangulation) encroached(e int) bool {
+	c := t.coords
+	u := t.Triangles[e]
+	v := t.Triangles[NextHalfedge(e)]
+	for _, edge := range []int{e, t.Halfedges[e]} {
+		if edge == -1 {
+			continue
+		}
+		w := t.Triangles[PrevHalfedge(edge)]
+		dot := (c[u*2]-c[w*2])*(c[v*2]-c[w*2]) + (c[u*2+1]-c[w*2+1])*(c[v*2+1]-c[w*2+1])
+		if dot < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encroachedBy returns the segments that a new point at x, y would encroach, starting from the triangle or
+// edge where the point is. Only segments that would be next to the new point are checked.
+func (t *Triangulation) encroachedBy(start int, x, y float64) [][2]int {
+	c := t.coords
+	tri := start - start%3
+	seen := map[int]bool{tri: true}
+	stack := []int{tri}
+	result := [][2]int{}
+	for len(stack) > 0 {
+		tri = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for e := tri; e < tri+3; e++ {
+			u := t.Triangles[e]
+			v := t.Triangles[NextHalfedge(e)]
+			if t.isSegment(e) {
+				if t.encroachedAt(e, x, y) {
+					result = append(result, [2]int{u, v})
+				}
+				continue
+			}
+			o := t.Halfedges[e] - t.Halfedges[e]%3
+			if seen[o] {
+				continue
+			}
+			p0, p1, p2 := t.Triangles[o], t.Triangles[o+1], t.Triangles[o+2]
+			if inCircle(c[p0*2], c[p0*2+1], c[p1*2], c[p1*2+1], c[p2*2], c[p2*2+1], x, y) {
+				seen[o] = true
+				stack = append(stack, o)
+			}
+		}
+	}
+	return result
+}
+
+// encroachedAt reports whether x, y is inside the circle that has edge e as its diameter.
+func (t *Triangulation) encroachedAt(e int, x, y float64) bool {
+	c := t.coords
+	u := t.Triangles[e]
+	v := t.Triangles[NextHalfedge(e)]
+	mx := (c[u*2] + c[v*2]) / 2
+	my := (c[u*2+1] + c[v*2+1]) / 2
+	return dist(x, y, mx, my) < dist(c[u*2], c[u*2+1], c[v*2], c[v*2+1])/4
+}
+
+// length returns the length of edge e.
+func (t *Triangulation) length(e int) float64 {
+	c := t.coords
+	u := t.Triangles[e]
+	v := t.Triangles[NextHalfedge(e)]
+	return math.Sqrt(dist(c[u*2], c[u*2+1], c[v*2], c[v*2+1]))
+}
+
+// locate walks in a straight line from the center of a triangle to x, y. It returns the triangle containing
+// the point, the edge it's on, or the segment blocking the way, along with which of those it found.
+func (t *Triangulation) locate(tri int, x, y float64) (int, int) {
+	c := t.coords
+	tri -= tri % 3
+	sx := (c[t.Triangles[tri]*2] + c[t.Triangles[tri+1]*2] + c[t.Triangles[tri+2]*2]) / 3
+	sy := (c[t.Triangles[tri]*2+1] + c[t.Triangles[tri+1]*2+1] + c[t.Triangles[tri+2]*2+1]) / 3
+	entry := -1
+	for steps := 0; steps < len(t.Triangles); steps++ {
+		// leave through an edge that the point is on the far side of, preferring the one the line crosses.
+		exit := -1
+		for e := tri; e < tri+3; e++ {
+			if e == entry {
+				continue
+			}
+			p := t.Triangles[e]
+			q := t.Triangles[NextHalfedge(e)]
+			r := t.Triangles[PrevHalfedge(e)]
+			side := crossXY(c[p*2], c[p*2+1], c[q*2], c[q*2+1], x, y)
+			if side*t.cross(p, q, r) >= 0 {
+				continue
+			}
+			straddles := crossXY(sx, sy, x, y, c[p*2], c[p*2+1])*crossXY(sx, sy, x, y, c[q*2], c[q*2+1]) <= 0
+			if exit == -1 || straddles {
+				exit = e
+			}
+		}
+		if exit == -1 {
+			for e := tri; e < tri+3; e++ {
+				p := t.Triangles[e]
+				q := t.Triangles[NextHalfedge(e)]
+				if x == c[p*2] && y == c[p*2+1] {
+					return -1, locateFailed
+				}
+				if crossXY(c[p*2], c[p*2+1], c[q*2], c[q*2+1], x, y) == 0 {
+					return e, locateEdge
+				}
+			}
+			return tri, locateInside
+		}
+		if t.isSegment(exit) {
+			return exit, locateBlocked
+		}
+		entry = t.Halfedges[exit]
+		tri = entry - entry%3
+	}
+	return -1, locateFailed
+}
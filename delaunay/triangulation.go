@@ -0,0 +1,488 @@
+// Package delaunay does triangulation
+package delaunay
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// epsilon is the distance under which two points are treated as the same point.
+var epsilon = math.Pow(2, -52)
+
+// Triangulation is a Delaunay triangulation stored as arrays of indices, built with the sweep hull method.
+// It is based on the Delaunator library, https://github.com/mapbox/delaunator.
+type Triangulation struct {
+	// Points is the list of points that was triangulated. Constrain and Refine can add points to the end.
+	Points geom.PointList
+	// Triangles holds the point indices of each triangle, three per triangle.
+	// Each triangle is wound the same way, counterclockwise on screen with y pointing down,
+	// so its points have a negative SignedArea.
+	Triangles []int
+	// Halfedges holds, for each edge in Triangles, the index of the matching edge in the neighboring triangle,
+	// or -1 if the edge is on the outside. Edge e runs from point Triangles[e] to point Triangles[NextHalfedge(e)].
+	Halfedges []int
+	// Hull holds the point indices of the convex hull, as built by NewTriangulation.
+	// It isn't updated by RemoveOutside or Refine.
+	Hull []int
+
+	coords      []float64
+	constraints map[[2]int]bool
+	inedge      []int
+	hullPrev    []int
+	hullNext    []int
+	hullTri     []int
+	hullHash    []int
+	hullStart   int
+	cx, cy      float64
+	stack       []int
+}
+
+// NewTriangulation triangulates a list of points. Duplicate points are skipped.
+// If there are fewer than three points, or they are all in a line, there are no triangles and
+// Hull holds the points in order along the line.
+func NewTriangulation(points geom.PointList) *Triangulation {
+	n := len(points)
+	t := &Triangulation{
+		// capped, so that adding points makes a new list rather than writing into spare room in the caller's.
+		Points:   points[:n:n],
+		coords:   make([]float64, n*2),
+		hullPrev: make([]int, n),
+		hullNext: make([]int, n),
+		hullTri:  make([]int, n),
+	}
+	for i, p := range points {
+		t.coords[i*2] = p.X
+		t.coords[i*2+1] = p.Y
+	}
+	maxTriangles := max(2*n-5, 0)
+	t.Triangles = make([]int, 0, maxTriangles*3)
+	t.Halfedges = make([]int, 0, maxTriangles*3)
+	if n > 0 {
+		t.triangulate()
+	}
+	return t
+}
+
+// NextHalfedge returns the next edge in the same triangle as edge e.
+func NextHalfedge(e int) int {
+	if e%3 == 2 {
+		return e - 2
+	}
+	return e + 1
+}
+
+// PrevHalfedge returns the previous edge in the same triangle as edge e.
+func PrevHalfedge(e int) int {
+	if e%3 == 0 {
+		return e + 2
+	}
+	return e - 1
+}
+
+// TriangleList returns the triangles as a geom.TriangleList. The triangles have their own copies of the points.
+func (t *Triangulation) TriangleList() geom.TriangleList {
+	list := geom.NewTriangleList()
+	for i := 0; i < len(t.Triangles); i += 3 {
+		a := t.Points[t.Triangles[i]]
+		b := t.Points[t.Triangles[i+1]]
+		c := t.Points[t.Triangles[i+2]]
+		list.Add(geom.NewTriangle(a.X, a.Y, b.X, b.Y, c.X, c.Y))
+	}
+	return list
+}
+
+// Edges returns each edge of the triangulation once, as a geom.SegmentList.
+func (t *Triangulation) Edges() geom.SegmentList {
+	list := geom.NewSegmentList()
+	for e, opposite := range t.Halfedges {
+		if e > opposite {
+			a := t.Points[t.Triangles[e]]
+			b := t.Points[t.Triangles[NextHalfedge(e)]]
+			list.Add(geom.NewSegment(a.X, a.Y, b.X, b.Y))
+		}
+	}
+	return list
+}
+
+//////////////////////////////
+// Sweep hull
+//////////////////////////////
+
+// triangulate sorts the points by distance from a seed triangle, then adds them one by one to a growing hull,
+// flipping edges as it goes to keep the triangulation Delaunay.
+func (t *Triangulation) triangulate() {
+	coords := t.coords
+	n := len(coords) / 2
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	ids := make([]int, n)
+	for i := 0; i < n; i++ {
+		x := coords[i*2]
+		y := coords[i*2+1]
+		minX = math.Min(minX, x)
+		minY = math.Min(minY, y)
+		maxX = math.Max(maxX, x)
+		maxY = math.Max(maxY, y)
+		ids[i] = i
+	}
+	cx := (minX + maxX) / 2
+	cy := (minY + maxY) / 2
+
+	// the seed triangle is the point closest to the center, the point closest to that,
+	// and the point making the smallest circumcircle with those two.
+	i0, i1, i2 := 0, 0, 0
+	minDist := math.Inf(1)
+	for i := 0; i < n; i++ {
+		d := dist(cx, cy, coords[i*2], coords[i*2+1])
+		if d < minDist {
+			i0 = i
+			minDist = d
+		}
+	}
+	i0x, i0y := coords[i0*2], coords[i0*2+1]
+
+	minDist = math.Inf(1)
+	for i := 0; i < n; i++ {
+		if i == i0 {
+			continue
+		}
+		d := dist(i0x, i0y, coords[i*2], coords[i*2+1])
+		if d < minDist && d > 0 {
+			i1 = i
+			minDist = d
+		}
+	}
+	i1x, i1y := coords[i1*2], coords[i1*2+1]
+
+	minRadius := math.Inf(1)
+	for i := 0; i < n; i++ {
+		if i == i0 || i == i1 {
+			continue
+		}
+		r := circumradius(i0x, i0y, i1x, i1y, coords[i*2], coords[i*2+1])
+		if r < minRadius {
+			i2 = i
+			minRadius = r
+		}
+	}
+	i2x, i2y := coords[i2*2], coords[i2*2+1]
+
+	dists := make([]float64, n)
+	if math.IsInf(minRadius, 1) {
+		// all the points are in a line, so there are no triangles. the hull is the points in order along the line.
+		for i := 0; i < n; i++ {
+			dists[i] = coords[i*2] - coords[0]
+			if dists[i] == 0 {
+				dists[i] = coords[i*2+1] - coords[1]
+			}
+		}
+		sort.Slice(ids, func(a, b int) bool { return dists[ids[a]] < dists[ids[b]] })
+		d0 := math.Inf(-1)
+		for _, id := range ids {
+			if dists[id] > d0 {
+				t.Hull = append(t.Hull, id)
+				d0 = dists[id]
+			}
+		}
+		return
+	}
+
+	// keep the seed triangle in a consistent winding.
+	if orient(i0x, i0y, i1x, i1y, i2x, i2y) {
+		i1, i2 = i2, i1
+		i1x, i1y, i2x, i2y = i2x, i2y, i1x, i1y
+	}
+
+	t.cx, t.cy = circumcenter(i0x, i0y, i1x, i1y, i2x, i2y)
+	for i := 0; i < n; i++ {
+		dists[i] = dist(coords[i*2], coords[i*2+1], t.cx, t.cy)
+	}
+	// ties are broken by index so that of any duplicate points, the first one is kept.
+	sort.Slice(ids, func(a, b int) bool {
+		da, db := dists[ids[a]], dists[ids[b]]
+		return da < db || (da == db && ids[a] < ids[b])
+	})
+
+	// the hull starts as the seed triangle, stored as a linked list with a hash of angles from the center
+	// for quickly finding the part of the hull that each new point can see.
+	t.hullHash = make([]int, int(math.Ceil(math.Sqrt(float64(n)))))
+	for i := range t.hullHash {
+		t.hullHash[i] = -1
+	}
+	t.hullStart = i0
+	hullSize := 3
+	t.hullNext[i0], t.hullPrev[i2] = i1, i1
+	t.hullNext[i1], t.hullPrev[i0] = i2, i2
+	t.hullNext[i2], t.hullPrev[i1] = i0, i0
+	t.hullTri[i0] = 0
+	t.hullTri[i1] = 1
+	t.hullTri[i2] = 2
+	t.hullHash[t.hashKey(i0x, i0y)] = i0
+	t.hullHash[t.hashKey(i1x, i1y)] = i1
+	t.hullHash[t.hashKey(i2x, i2y)] = i2
+
+	t.addTriangle(i0, i1, i2, -1, -1, -1)
+
+	xp, yp := 0.0, 0.0
+	for k, i := range ids {
+		x := coords[i*2]
+		y := coords[i*2+1]
+
+		// skip near duplicate points and the seed triangle.
+		if k > 0 && math.Abs(x-xp) <= epsilon && math.Abs(y-yp) <= epsilon {
+			continue
+		}
+		xp, yp = x, y
+		if i == i0 || i == i1 || i == i2 {
+			continue
+		}
+
+		// find a visible edge on the convex hull using the edge hash.
+		start := 0
+		key := t.hashKey(x, y)
+		for j := 0; j < len(t.hullHash); j++ {
+			start = t.hullHash[(key+j)%len(t.hullHash)]
+			if start != -1 && start != t.hullNext[start] {
+				break
+			}
+		}
+		start = t.hullPrev[start]
+		e := start
+		q := t.hullNext[e]
+		for !orient(x, y, coords[e*2], coords[e*2+1], coords[q*2], coords[q*2+1]) {
+			e = q
+			if e == start {
+				e = -1
+				break
+			}
+			q = t.hullNext[e]
+		}
+		if e == -1 {
+			// likely a near duplicate point, skip it.
+			continue
+		}
+
+		// add the first triangle from the point.
+		tri := t.addTriangle(e, i, t.hullNext[e], -1, -1, t.hullTri[e])
+		t.hullTri[i] = t.legalize(tri + 2)
+		t.hullTri[e] = tri
+		hullSize++
+
+		// walk forward through the hull, adding more triangles and flipping recursively.
+		next := t.hullNext[e]
+		q = t.hullNext[next]
+		for orient(x, y, coords[next*2], coords[next*2+1], coords[q*2], coords[q*2+1]) {
+			tri = t.addTriangle(next, i, q, t.hullTri[i], -1, t.hullTri[next])
+			t.hullTri[i] = t.legalize(tri + 2)
+			t.hullNext[next] = next // mark as removed
+			hullSize--
+			next = q
+			q = t.hullNext[next]
+		}
+
+		// walk backward from the other side, adding more triangles and flipping.
+		if e == start {
+			q = t.hullPrev[e]
+			for orient(x, y, coords[q*2], coords[q*2+1], coords[e*2], coords[e*2+1]) {
+				tri = t.addTriangle(q, i, e, -1, t.hullTri[e], t.hullTri[q])
+				t.legalize(tri + 2)
+				t.hullTri[q] = tri
+				t.hullNext[e] = e // mark as removed
+				hullSize--
+				e = q
+				q = t.hullPrev[e]
+			}
+		}
+
+		// update the hull indices.
+		t.hullStart = e
+		t.hullPrev[i] = e
+		t.hullNext[e] = i
+		t.hullPrev[next] = i
+		t.hullNext[i] = next
+
+		// save the two new edges in the hash table.
+		t.hullHash[t.hashKey(x, y)] = i
+		t.hullHash[t.hashKey(coords[e*2], coords[e*2+1])] = e
+	}
+
+	t.Hull = make([]int, hullSize)
+	e := t.hullStart
+	for i := 0; i < hullSize; i++ {
+		t.Hull[i] = e
+		e = t.hullNext[e]
+	}
+}
+
+// hashKey returns the hull hash bucket for a location, based on its angle from the center.
+func (t *Triangulation) hashKey(x, y float64) int {
+	size := len(t.hullHash)
+	return int(math.Floor(pseudoAngle(x-t.cx, y-t.cy)*float64(size))) % size
+}
+
+// legalize flips edges until the triangles around edge a are all Delaunay, returning the last edge.
+// It uses a stack rather than recursion.
+func (t *Triangulation) legalize(a int) int {
+	t.stack = t.stack[:0]
+	ar := 0
+	for {
+		b := t.Halfedges[a]
+
+		// if the pair of triangles doesn't satisfy the Delaunay condition (p1 is inside the circumcircle
+		// of [p0, pl, pr]), flip them, then do the same check for the adjacent pairs of triangles.
+		//
+		//           pl                    pl
+		//          /||\                  /  \
+		//       al/ || \bl            al/    \a
+		//        /  ||  \              /      \
+		//       /  a||b  \    flip    /___ar___\
+		//     p0\   ||   /p1   =>   p0\---bl---/p1
+		//        \  ||  /              \      /
+		//       ar\ || /br             b\    /br
+		//          \||/                  \  /
+		//           pr                    pr
+		a0 := a - a%3
+		ar = a0 + (a+2)%3
+
+		if b == -1 {
+			// convex hull edge
+			if len(t.stack) == 0 {
+				break
+			}
+			a = t.stack[len(t.stack)-1]
+			t.stack = t.stack[:len(t.stack)-1]
+			continue
+		}
+
+		b0 := b - b%3
+		al := a0 + (a+1)%3
+		bl := b0 + (b+2)%3
+
+		p0 := t.Triangles[ar]
+		pr := t.Triangles[a]
+		pl := t.Triangles[al]
+		p1 := t.Triangles[bl]
+
+		c := t.coords
+		illegal := inCircle(c[p0*2], c[p0*2+1], c[pr*2], c[pr*2+1], c[pl*2], c[pl*2+1], c[p1*2], c[p1*2+1])
+		if illegal {
+			t.Triangles[a] = p1
+			t.Triangles[b] = p0
+
+			hbl := t.Halfedges[bl]
+
+			// the edge was swapped on the other side of the hull (rare), so fix the halfedge reference.
+			if hbl == -1 {
+				e := t.hullStart
+				for {
+					if t.hullTri[e] == bl {
+						t.hullTri[e] = a
+						break
+					}
+					e = t.hullPrev[e]
+					if e == t.hullStart {
+						break
+					}
+				}
+			}
+			t.link(a, hbl)
+			t.link(b, t.Halfedges[ar])
+			t.link(ar, bl)
+
+			br := b0 + (b+1)%3
+			t.stack = append(t.stack, br)
+		} else {
+			if len(t.stack) == 0 {
+				break
+			}
+			a = t.stack[len(t.stack)-1]
+			t.stack = t.stack[:len(t.stack)-1]
+		}
+	}
+	return ar
+}
+
+// link connects two matching halfedges.
+func (t *Triangulation) link(a, b int) {
+	t.Halfedges[a] = b
+	if b != -1 {
+		t.Halfedges[b] = a
+	}
+}
+
+// addTriangle adds a triangle and links its edges to their neighbors, returning the index of its first edge.
+func (t *Triangulation) addTriangle(i0, i1, i2, a, b, c int) int {
+	e := len(t.Triangles)
+	t.Triangles = append(t.Triangles, i0, i1, i2)
+	t.Halfedges = append(t.Halfedges, -1, -1, -1)
+	t.link(e, a)
+	t.link(e+1, b)
+	t.link(e+2, c)
+	return e
+}
+
+// pseudoAngle returns a number from 0 to 1 that increases with the angle of a vector, without using trig.
+func pseudoAngle(dx, dy float64) float64 {
+	p := dx / (math.Abs(dx) + math.Abs(dy))
+	if dy > 0 {
+		return (3 - p) / 4
+	}
+	return (1 + p) / 4
+}
+
+// dist returns the squared distance between two points.
+func dist(ax, ay, bx, by float64) float64 {
+	dx := ax - bx
+	dy := ay - by
+	return dx*dx + dy*dy
+}
+
+// orient reports whether r, q, p wind counterclockwise on screen.
+func orient(rx, ry, qx, qy, px, py float64) bool {
+	return (qy-ry)*(px-qx)-(qx-rx)*(py-qy) < 0
+}
+
+// inCircle reports whether p is inside the circumcircle of a, b, c.
+func inCircle(ax, ay, bx, by, cx, cy, px, py float64) bool {
+	dx := ax - px
+	dy := ay - py
+	ex := bx - px
+	ey := by - py
+	fx := cx - px
+	fy := cy - py
+
+	ap := dx*dx + dy*dy
+	bp := ex*ex + ey*ey
+	cp := fx*fx + fy*fy
+
+	return dx*(ey*cp-bp*fy)-dy*(ex*cp-bp*fx)+ap*(ex*fy-ey*fx) < 0
+}
+
+// circumradius returns the squared radius of the circle through three points.
+func circumradius(ax, ay, bx, by, cx, cy float64) float64 {
+	x, y := circumOffset(ax, ay, bx, by, cx, cy)
+	return x*x + y*y
+}
+
+// circumcenter returns the center of the circle through three points.
+func circumcenter(ax, ay, bx, by, cx, cy float64) (float64, float64) {
+	x, y := circumOffset(ax, ay, bx, by, cx, cy)
+	return ax + x, ay + y
+}
+
+// circumOffset returns the offset from a to the center of the circle through a, b and c.
+func circumOffset(ax, ay, bx, by, cx, cy float64) (float64, float64) {
+	dx := bx - ax
+	dy := by - ay
+	ex := cx - ax
+	ey := cy - ay
+
+	bl := dx*dx + dy*dy
+	cl := ex*ex + ey*ey
+	d := 0.5 / (dx*ey - dy*ex)
+
+	return (ey*bl - dy*cl) * d, (dx*cl - ex*bl) * d
+}
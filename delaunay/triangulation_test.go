@@ -0,0 +1,123 @@
+// Package delaunay does triangulation
+package delaunay
+
+import (
+	"testing"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// checkTriangulation reports any broken halfedge links or badly wound triangles.
+func checkTriangulation(t *testing.T, tri *Triangulation) {
+	if len(tri.Triangles)%3 != 0 || len(tri.Halfedges) != len(tri.Triangles) {
+		t.Fatalf("Expected matching triangles and halfedges, got %d and %d\n", len(tri.Triangles), len(tri.Halfedges))
+	}
+	for e, opposite := range tri.Halfedges {
+		if opposite == -1 {
+			continue
+		}
+		if tri.Halfedges[opposite] != e {
+			t.Errorf("Expected halfedge %d to link back to %d, got %d\n", opposite, e, tri.Halfedges[opposite])
+		}
+		// the matching edge runs the other way.
+		if tri.Triangles[e] != tri.Triangles[NextHalfedge(opposite)] || tri.Triangles[NextHalfedge(e)] != tri.Triangles[opposite] {
+			t.Errorf("Expected halfedges %d and %d to join the same points\n", e, opposite)
+		}
+	}
+	for i := 0; i < len(tri.Triangles); i += 3 {
+		triangle := geom.PointList{tri.Points[tri.Triangles[i]], tri.Points[tri.Triangles[i+1]], tri.Points[tri.Triangles[i+2]]}
+		if triangle.SignedArea() >= 0 {
+			t.Errorf("Expected a negative area, got %f\n", triangle.SignedArea())
+		}
+	}
+}
+
+func TestTriangulationRandom(t *testing.T) {
+	points := randomPoints(4, 300, geom.NewRect(0, 0, 500, 500))
+	tri := NewTriangulation(points)
+	checkTriangulation(t, tri)
+	// a triangulation of n points with h on the hull has 2n - 2 - h triangles.
+	expected := 2*len(points) - 2 - len(tri.Hull)
+	if len(tri.Triangles)/3 != expected {
+		t.Errorf("Expected %d, got %d\n", expected, len(tri.Triangles)/3)
+	}
+	// no point is inside the circumcircle of any triangle.
+	for i := 0; i < len(tri.Triangles); i += 3 {
+		a, b, c := points[tri.Triangles[i]], points[tri.Triangles[i+1]], points[tri.Triangles[i+2]]
+		cx, cy := circumcenter(a.X, a.Y, b.X, b.Y, c.X, c.Y)
+		center := geom.NewPoint(cx, cy)
+		r := center.Distance(a)
+		for j, p := range points {
+			if j == tri.Triangles[i] || j == tri.Triangles[i+1] || j == tri.Triangles[i+2] {
+				continue
+			}
+			if center.Distance(p) < r*(1-1e-9) {
+				t.Errorf("Expected point %d to be outside the circumcircle of triangle %d\n", j, i/3)
+			}
+		}
+	}
+}
+
+func TestTriangulationDuplicates(t *testing.T) {
+	points := geom.NewPointList()
+	points.AddXY(0, 0)
+	points.AddXY(10, 0)
+	points.AddXY(10, 10)
+	points.AddXY(0, 10)
+	points.AddXY(10, 0)
+	points.AddXY(0, 0)
+	points.AddXY(5, 5)
+	tri := NewTriangulation(points)
+	checkTriangulation(t, tri)
+	if len(tri.Triangles)/3 != 4 {
+		t.Errorf("Expected %d, got %d\n", 4, len(tri.Triangles)/3)
+	}
+	for _, i := range tri.Triangles {
+		if i == 4 || i == 5 {
+			t.Errorf("Expected duplicate point %d to be skipped\n", i)
+		}
+	}
+}
+
+func TestTriangulationCollinear(t *testing.T) {
+	points := geom.NewPointList()
+	for _, x := range []float64{3, 0, 4, 1, 2} {
+		points.AddXY(x, x*2)
+	}
+	tri := NewTriangulation(points)
+	if len(tri.Triangles) != 0 {
+		t.Errorf("Expected no triangles, got %d\n", len(tri.Triangles)/3)
+	}
+	// the hull holds the points in order along the line.
+	if len(tri.Hull) != 5 {
+		t.Fatalf("Expected %d, got %d\n", 5, len(tri.Hull))
+	}
+	for i := 1; i < len(tri.Hull); i++ {
+		if points[tri.Hull[i]].X <= points[tri.Hull[i-1]].X {
+			t.Errorf("Expected the hull in order along the line, got %v\n", tri.Hull)
+			break
+		}
+	}
+
+	// one point off the line gives a fan of triangles.
+	points.AddXY(0, 5)
+	tri = NewTriangulation(points)
+	checkTriangulation(t, tri)
+	if len(tri.Triangles)/3 != 4 {
+		t.Errorf("Expected %d, got %d\n", 4, len(tri.Triangles)/3)
+	}
+}
+
+func TestTriangulationSmall(t *testing.T) {
+	for count := 0; count < 3; count++ {
+		tri := NewTriangulation(randomPoints(5, count, geom.NewRect(0, 0, 10, 10)))
+		if len(tri.Triangles) != 0 {
+			t.Errorf("Expected no triangles, got %d\n", len(tri.Triangles)/3)
+		}
+	}
+	tri := NewTriangulation(randomPoints(5, 3, geom.NewRect(0, 0, 10, 10)))
+	checkTriangulation(t, tri)
+	if len(tri.Triangles) != 3 {
+		t.Errorf("Expected one triangle, got %d\n", len(tri.Triangles)/3)
+	}
+}
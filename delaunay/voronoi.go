@@ -0,0 +1,219 @@
+// Package delaunay does triangulation
+package delaunay
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// Cell is a single cell of a Voronoi diagram: the area closer to its site than to any other site.
+type Cell struct {
+	// Site is the point the cell belongs to.
+	Site *geom.Point
+	// Polygon is the outline of the cell, clipped to the bounds. It is empty if the cell is entirely out of bounds.
+	Polygon geom.PointList
+	// Neighbors holds the indices of the sites whose cells share an edge with this one inside the bounds.
+	Neighbors []int
+}
+
+// Voronoi creates the Voronoi diagram of a list of points, clipped to bounds.
+// It returns one cell for each point, in the same order.
+// Duplicate points after the first get an empty cell.
+// If bounds is nil, the bounding box of the points is used.
+func Voronoi(points geom.PointList, bounds *geom.Rect) []*Cell {
+	cells := make([]*Cell, len(points))
+	seen := map[[2]float64]bool{}
+	sites := geom.NewPointList()
+	// index maps each site back to its position in points.
+	index := []int{}
+	for i, p := range points {
+		cells[i] = &Cell{Site: p, Polygon: geom.NewPointList()}
+		key := [2]float64{p.X, p.Y}
+		if !seen[key] {
+			seen[key] = true
+			sites.Add(p)
+			index = append(index, i)
+		}
+	}
+	if len(sites) == 0 {
+		return cells
+	}
+	if bounds == nil {
+		bounds = sites.BoundingBox()
+	}
+
+	// ghost sites far outside the bounds close off the cells around the edge.
+	// they are further from the bounds than any point in the bounds is from any site, so they never clip a cell.
+	area := sites.BoundingBox()
+	x0 := math.Min(area.X, bounds.X)
+	y0 := math.Min(area.Y, bounds.Y)
+	x1 := math.Max(area.X+area.W, bounds.X+bounds.W)
+	y1 := math.Max(area.Y+area.H, bounds.Y+bounds.H)
+	size := math.Max(math.Hypot(x1-x0, y1-y0), 1) * 3
+	cx := (x0 + x1) / 2
+	cy := (y0 + y1) / 2
+	all := sites.Clone()
+	all.AddXY(cx-size, cy-size)
+	all.AddXY(cx+size, cy-size)
+	all.AddXY(cx+size, cy+size)
+	all.AddXY(cx-size, cy+size)
+
+	// collect the circumcenters around each site, and the two triangles on each edge between sites.
+	// ghost sites come after the real ones, so they are left out.
+	tri := NewTriangulation(all)
+	count := len(sites)
+	centers := map[int]geom.PointList{}
+	edges := map[[2]int]geom.PointList{}
+	for i := 0; i < len(tri.Triangles); i += 3 {
+		a, b, c := all[tri.Triangles[i]], all[tri.Triangles[i+1]], all[tri.Triangles[i+2]]
+		x, y := circumcenter(a.X, a.Y, b.X, b.Y, c.X, c.Y)
+		center := geom.NewPoint(x, y)
+		for e := i; e < i+3; e++ {
+			p := tri.Triangles[e]
+			q := tri.Triangles[NextHalfedge(e)]
+			if p < count {
+				centers[index[p]] = append(centers[index[p]], center)
+			}
+			if p < count && q < count {
+				key := [2]int{min(index[p], index[q]), max(index[p], index[q])}
+				edges[key] = append(edges[key], center)
+			}
+		}
+	}
+
+	for i, list := range centers {
+		site := cells[i].Site
+		sort.Slice(list, func(a, b int) bool {
+			return math.Atan2(list[a].Y-site.Y, list[a].X-site.X) < math.Atan2(list[b].Y-site.Y, list[b].X-site.X)
+		})
+		cells[i].Polygon = clipPolygon(list.Unique(), bounds)
+	}
+
+	// each edge between two sites is matched by the cell edge joining the circumcenters on either side of it.
+	// four or more sites on a circle share a circumcenter, and the cells across from each other only meet at that point.
+	eps := size * 1e-10
+	keys := make([][2]int, 0, len(edges))
+	for key := range edges {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a][0] == keys[b][0] {
+			return keys[a][1] < keys[b][1]
+		}
+		return keys[a][0] < keys[b][0]
+	})
+	for _, key := range keys {
+		list := edges[key]
+		if len(list) == 2 && list[0].Distance(list[1]) > eps && clipSegment(list[0], list[1], bounds) {
+			cells[key[0]].Neighbors = append(cells[key[0]].Neighbors, key[1])
+			cells[key[1]].Neighbors = append(cells[key[1]].Neighbors, key[0])
+		}
+	}
+	return cells
+}
+
+// Centroid returns the center of mass of the cell's polygon, or the site if the polygon is empty.
+func (c *Cell) Centroid() *geom.Point {
+	area := 0.0
+	x, y := 0.0, 0.0
+	count := len(c.Polygon)
+	for i, p := range c.Polygon {
+		q := c.Polygon[(i+1)%count]
+		cross := p.X*q.Y - q.X*p.Y
+		area += cross
+		x += (p.X + q.X) * cross
+		y += (p.Y + q.Y) * cross
+	}
+	if math.Abs(area) < 1e-12 {
+		return c.Site.Clone()
+	}
+	return geom.NewPoint(x/(3*area), y/(3*area))
+}
+
+// Relax moves each point to the centroid of its Voronoi cell, the given number of times.
+// This is Lloyd's algorithm, which spreads points out evenly. The original list is not changed.
+func Relax(points geom.PointList, bounds *geom.Rect, iterations int) geom.PointList {
+	points = points.Clone()
+	for i := 0; i < iterations; i++ {
+		for j, c := range Voronoi(points, bounds) {
+			points[j] = c.Centroid()
+		}
+	}
+	return points
+}
+
+// clipPolygon clips a convex polygon to a rect, using Sutherland-Hodgman clipping against each side.
+func clipPolygon(polygon geom.PointList, r *geom.Rect) geom.PointList {
+	// each side is described by which coord it tests and the limit, and whether inside is above or below it.
+	type side struct {
+		vertical bool
+		limit    float64
+		below    bool
+	}
+	sides := []side{
+		{true, r.X, false},
+		{true, r.X + r.W, true},
+		{false, r.Y, false},
+		{false, r.Y + r.H, true},
+	}
+	for _, s := range sides {
+		if len(polygon) == 0 {
+			break
+		}
+		value := func(p *geom.Point) float64 {
+			if s.vertical {
+				return p.X
+			}
+			return p.Y
+		}
+		inside := func(p *geom.Point) bool {
+			if s.below {
+				return value(p) <= s.limit
+			}
+			return value(p) >= s.limit
+		}
+		result := geom.NewPointList()
+		for i, p := range polygon {
+			q := polygon[(i+1)%len(polygon)]
+			if inside(p) {
+				result.Add(p)
+			}
+			if inside(p) != inside(q) {
+				t := (s.limit - value(p)) / (value(q) - value(p))
+				result.Add(geom.LerpPoint(t, p, q))
+			}
+		}
+		polygon = result
+	}
+	return polygon
+}
+
+// clipSegment reports whether any of the segment from p to q is inside a rect, using Liang-Barsky clipping.
+func clipSegment(p, q *geom.Point, r *geom.Rect) bool {
+	dx := q.X - p.X
+	dy := q.Y - p.Y
+	t0, t1 := 0.0, 1.0
+	checks := [][2]float64{
+		{-dx, p.X - r.X},
+		{dx, r.X + r.W - p.X},
+		{-dy, p.Y - r.Y},
+		{dy, r.Y + r.H - p.Y},
+	}
+	for _, c := range checks {
+		if c[0] == 0 {
+			if c[1] < 0 {
+				return false
+			}
+			continue
+		}
+		t := c[1] / c[0]
+		if c[0] < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+	}
+	return t1-t0 > 1e-9
+}
@@ -0,0 +1,167 @@
+// Package delaunay does triangulation
+package delaunay
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+	"github.com/bit101/bitlib/geom"
+)
+
+// randomPoints returns count points inside a rect, the same each time for a seed.
+func randomPoints(seed int64, count int, r *geom.Rect) geom.PointList {
+	rng := rand.New(rand.NewSource(seed))
+	points := geom.NewPointList()
+	for i := 0; i < count; i++ {
+		points.AddXY(r.X+rng.Float64()*r.W, r.Y+rng.Float64()*r.H)
+	}
+	return points
+}
+
+// gridPoints returns the centers of a grid of square cells, size units across.
+func gridPoints(cols, rows int, size float64) geom.PointList {
+	points := geom.NewPointList()
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			points.AddXY((float64(x)+0.5)*size, (float64(y)+0.5)*size)
+		}
+	}
+	return points
+}
+
+// countLinks returns the number of neighbor links between cells, counting both directions,
+// and reports an error if any link is one way.
+func countLinks(t *testing.T, cells []*Cell) int {
+	links := 0
+	for i, c := range cells {
+		for _, n := range c.Neighbors {
+			found := false
+			for _, m := range cells[n].Neighbors {
+				found = found || m == i
+			}
+			if !found {
+				t.Errorf("Expected %d to be a neighbor of %d\n", i, n)
+			}
+			links++
+		}
+	}
+	return links
+}
+
+func TestVoronoiSquare(t *testing.T) {
+	// the four sites are on a circle, so all the cells meet at the center.
+	// only cells sharing an edge are neighbors, not the ones across the diagonals.
+	cells := Voronoi(gridPoints(2, 2, 10), geom.NewRect(0, 0, 20, 20))
+	expected := [][]int{{1, 2}, {0, 3}, {0, 3}, {1, 2}}
+	for i, c := range cells {
+		if len(c.Neighbors) != 2 || c.Neighbors[0] != expected[i][0] || c.Neighbors[1] != expected[i][1] {
+			t.Errorf("Expected %v, got %v\n", expected[i], c.Neighbors)
+		}
+		area := math.Abs(c.Polygon.SignedArea())
+		if !blmath.Equalish(area, 100, 1e-9) {
+			t.Errorf("Expected %f, got %f\n", 100.0, area)
+		}
+	}
+}
+
+func TestVoronoiGrid(t *testing.T) {
+	cells := Voronoi(gridPoints(10, 10, 10), geom.NewRect(0, 0, 100, 100))
+	// 10 rows of 9 pairs across and 10 columns of 9 pairs down, each listing the other.
+	links := countLinks(t, cells)
+	if links != 360 {
+		t.Errorf("Expected %d, got %d\n", 360, links)
+	}
+	for _, c := range cells {
+		area := math.Abs(c.Polygon.SignedArea())
+		if !blmath.Equalish(area, 100, 1e-6) {
+			t.Errorf("Expected %f, got %f\n", 100.0, area)
+		}
+	}
+}
+
+func TestVoronoiArea(t *testing.T) {
+	bounds := geom.NewRect(10, 20, 300, 200)
+	// some of the points are outside the bounds, and one is a duplicate.
+	points := randomPoints(1, 200, geom.NewRect(0, 0, 340, 240))
+	points.Add(points[5].Clone())
+	cells := Voronoi(points, bounds)
+	if len(cells) != len(points) {
+		t.Errorf("Expected %d, got %d\n", len(points), len(cells))
+	}
+	if len(cells[200].Polygon) != 0 {
+		t.Errorf("Expected an empty cell for a duplicate point, got %d points\n", len(cells[200].Polygon))
+	}
+	total := 0.0
+	for i, c := range cells {
+		total += math.Abs(c.Polygon.SignedArea())
+		// every site is closer to its own cell than to any other site.
+		for _, p := range c.Polygon {
+			d := p.Distance(c.Site)
+			for j, other := range points[:200] {
+				if j != i && p.Distance(other) < d-1e-6 {
+					t.Errorf("Expected cell %d to be closest to its site\n", i)
+				}
+			}
+		}
+	}
+	if !blmath.Equalish(total, bounds.W*bounds.H, 1e-6) {
+		t.Errorf("Expected %f, got %f\n", bounds.W*bounds.H, total)
+	}
+}
+
+func TestVoronoiNilBounds(t *testing.T) {
+	points := randomPoints(2, 50, geom.NewRect(0, 0, 100, 100))
+	cells := Voronoi(points, nil)
+	box := points.BoundingBox()
+	total := 0.0
+	for _, c := range cells {
+		total += math.Abs(c.Polygon.SignedArea())
+	}
+	if !blmath.Equalish(total, box.W*box.H, 1e-6) {
+		t.Errorf("Expected %f, got %f\n", box.W*box.H, total)
+	}
+}
+
+func TestRelax(t *testing.T) {
+	bounds := geom.NewRect(0, 0, 100, 100)
+	points := randomPoints(3, 50, bounds)
+	original := points.Clone()
+	relaxed := Relax(points, bounds, 20)
+	if len(relaxed) != len(points) {
+		t.Errorf("Expected %d, got %d\n", len(points), len(relaxed))
+	}
+	for i, p := range points {
+		if !p.Equals(original[i]) {
+			t.Errorf("Expected the original points to be unchanged\n")
+			break
+		}
+	}
+	for _, p := range relaxed {
+		if !bounds.Contains(p) {
+			t.Errorf("Expected %v to be inside the bounds\n", *p)
+		}
+	}
+	// relaxing spreads the points out, so the closest pair gets further apart.
+	if closest(relaxed) <= closest(points)*2 {
+		t.Errorf("Expected the points to spread out, got %f before and %f after\n", closest(points), closest(relaxed))
+	}
+	// once relaxed, each point is at the centroid of its cell.
+	for _, c := range Voronoi(relaxed, bounds) {
+		if c.Centroid().Distance(c.Site) > 0.5 {
+			t.Errorf("Expected a site near its centroid, got %f away\n", c.Centroid().Distance(c.Site))
+		}
+	}
+}
+
+// closest returns the distance between the closest pair of points.
+func closest(points geom.PointList) float64 {
+	d := math.Inf(1)
+	for i, p := range points {
+		for _, q := range points[i+1:] {
+			d = math.Min(d, p.Distance(q))
+		}
+	}
+	return d
+}
@@ -0,0 +1,79 @@
+// Package flowfield traces lines through vector fields.
+package flowfield
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/blmath"
+	"github.com/bit101/bitlib/noise"
+)
+
+// curlEpsilon is the step used when estimating the curl of a potential by finite differences.
+const curlEpsilon = 1e-4
+
+// Field gives a flow vector at any location.
+type Field struct {
+	vector func(x, y float64) (float64, float64)
+}
+
+// NewVectorField creates a field from a function returning a flow vector.
+func NewVectorField(f func(x, y float64) (float64, float64)) *Field {
+	return &Field{f}
+}
+
+// NewAngleField creates a field from a function returning a flow angle in radians.
+func NewAngleField(f func(x, y float64) float64) *Field {
+	return &Field{func(x, y float64) (float64, float64) {
+		angle := f(x, y)
+		return math.Cos(angle), math.Sin(angle)
+	}}
+}
+
+// NewNoiseField creates a field whose angle comes from a noise function, such as noise.Perlin2.
+// The location is multiplied by scale before sampling the noise, and the result is mapped to an
+// angle of up to one full turn in either direction.
+func NewNoiseField(f noise.Func, scale float64) *Field {
+	return NewAngleField(func(x, y float64) float64 {
+		return f(x*scale, y*scale) * blmath.Tau
+	})
+}
+
+// NewCurlField creates a field that flows along the contours of a potential function.
+// The flow is the curl of the potential, so it has no sources or sinks and lines never bunch up or spread out.
+func NewCurlField(potential func(x, y float64) float64) *Field {
+	return &Field{func(x, y float64) (float64, float64) {
+		dx := (potential(x+curlEpsilon, y) - potential(x-curlEpsilon, y)) / (2 * curlEpsilon)
+		dy := (potential(x, y+curlEpsilon) - potential(x, y-curlEpsilon)) / (2 * curlEpsilon)
+		return dy, -dx
+	}}
+}
+
+// NewCurlNoiseField creates a curl field from simplex noise, using its analytic derivatives.
+// The location is multiplied by scale before sampling the noise.
+func NewCurlNoiseField(n *noise.Noise, scale float64) *Field {
+	return &Field{func(x, y float64) (float64, float64) {
+		_, dx, dy := n.Simplex2D(x*scale, y*scale)
+		return dy * scale, -dx * scale
+	}}
+}
+
+// Vector returns the flow vector at the given location.
+func (f *Field) Vector(x, y float64) (float64, float64) {
+	return f.vector(x, y)
+}
+
+// Angle returns the flow angle at the given location.
+func (f *Field) Angle(x, y float64) float64 {
+	vx, vy := f.vector(x, y)
+	return math.Atan2(vy, vx)
+}
+
+// direction returns the unit flow vector at the given location, and false if the flow is zero.
+func (f *Field) direction(x, y float64) (float64, float64, bool) {
+	vx, vy := f.vector(x, y)
+	l := math.Hypot(vx, vy)
+	if l < 1e-12 || math.IsNaN(l) {
+		return 0, 0, false
+	}
+	return vx / l, vy / l, true
+}
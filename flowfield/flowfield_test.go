@@ -0,0 +1,124 @@
+// Package flowfield traces lines through vector fields.
+package flowfield
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+	"github.com/bit101/bitlib/geom"
+)
+
+func TestTraceUniform(t *testing.T) {
+	field := NewAngleField(func(x, y float64) float64 { return 0 })
+	tracer := NewTracer(field)
+	tracer.MaxSteps = 10
+	line := tracer.Trace(0, 5)
+	if len(line) != 11 {
+		t.Fatalf("Expected %d, got %d\n", 11, len(line))
+	}
+	last := line.Last()
+	if !blmath.Equalish(last.X, 10, 1e-9) || !blmath.Equalish(last.Y, 5, 1e-9) {
+		t.Errorf("Expected (10, 5), got (%f, %f)\n", last.X, last.Y)
+	}
+
+	// backwards as well, and stopped by the bounds.
+	tracer.Bidirectional = true
+	tracer.Bounds = geom.NewRect(-3.5, 0, 10, 10)
+	line = tracer.Trace(0, 5)
+	if !blmath.Equalish(line.First().X, -3, 1e-9) || !blmath.Equalish(line.Last().X, 6, 1e-9) {
+		t.Errorf("Expected -3 to 6, got %f to %f\n", line.First().X, line.Last().X)
+	}
+}
+
+func TestTraceIntegrator(t *testing.T) {
+	// circles around the origin. euler spirals outwards, rk4 stays close.
+	field := NewCurlField(func(x, y float64) float64 { return x*x + y*y })
+	type test struct {
+		integrator Integrator
+		tolerance  float64
+	}
+	tests := []test{
+		{Euler, 5},
+		{RK4, 0.01},
+	}
+	for _, test := range tests {
+		tracer := NewTracer(field)
+		tracer.Integrator = test.integrator
+		tracer.MaxSteps = 300
+		line := tracer.Trace(50, 0)
+		for _, p := range line {
+			r := math.Hypot(p.X, p.Y)
+			if math.Abs(r-50) > test.tolerance {
+				t.Errorf("Expected %f, got %f\n", 50.0, r)
+				break
+			}
+		}
+	}
+}
+
+func TestTraceSeparation(t *testing.T) {
+	field := NewAngleField(func(x, y float64) float64 { return 0 })
+	tracer := NewTracer(field)
+	tracer.MaxSteps = 100
+	tracer.Separation = 5
+	tracer.Trace(50, 0)
+
+	// too close to start.
+	if line := tracer.Trace(70, 3); line != nil {
+		t.Errorf("Expected nil, got %d points\n", len(line))
+	}
+
+	// heading straight for the first line, stops before it.
+	tracer.Field = NewAngleField(func(x, y float64) float64 { return math.Pi / 2 })
+	line := tracer.Trace(60, -20)
+	if d := -line.Last().Y; d < 5 || d > 6 {
+		t.Errorf("Expected 5 to 6, got %f\n", d)
+	}
+
+	// forgotten after reset.
+	tracer.Reset()
+	line = tracer.Trace(60, -20)
+	if len(line) != 101 {
+		t.Errorf("Expected %d, got %d\n", 101, len(line))
+	}
+}
+
+func TestEvenlySpaced(t *testing.T) {
+	field := NewAngleField(func(x, y float64) float64 { return 0.3 })
+	tracer := NewTracer(field)
+	lines := tracer.EvenlySpaced(geom.NewRect(0, 0, 100, 100), 10)
+	if len(lines) < 10 {
+		t.Fatalf("Expected at least %d lines, got %d\n", 10, len(lines))
+	}
+	// no line comes closer to another than half the separation.
+	for i, a := range lines {
+		for _, b := range lines[i+1:] {
+			for _, p := range a {
+				for _, q := range b {
+					if d := p.Distance(q); d < 5-1e-9 {
+						t.Fatalf("Expected at least %f, got %f\n", 5.0, d)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestEvenlySpacedBadParams(t *testing.T) {
+	field := NewAngleField(func(x, y float64) float64 { return 0.3 })
+	tracer := NewTracer(field)
+	bounds := geom.NewRect(0, 0, 100, 100)
+	for _, separation := range []float64{0, -10} {
+		if lines := tracer.EvenlySpaced(bounds, separation); lines != nil {
+			t.Errorf("Expected nil for separation %f, got %d lines\n", separation, len(lines))
+		}
+	}
+	tracer.StepSize = 0
+	if lines := tracer.EvenlySpaced(bounds, 10); lines != nil {
+		t.Errorf("Expected nil for a step size of 0, got %d lines\n", len(lines))
+	}
+	if line := tracer.Trace(50, 50); line != nil {
+		t.Errorf("Expected nil for a step size of 0, got %d points\n", len(line))
+	}
+}
@@ -0,0 +1,55 @@
+// Package flowfield traces lines through vector fields.
+package flowfield
+
+import (
+	"math"
+)
+
+// entry is a point stored in a grid, along with its position along the line it belongs to.
+type entry struct {
+	x, y  float64
+	index int
+}
+
+// grid is a spatial hash of points, for quickly finding whether any point is near a location.
+type grid struct {
+	size  float64
+	cells map[[2]int][]entry
+}
+
+// newGrid creates a grid with the given cell size.
+// Searches are quickest when the cell size is about the same as the search radius.
+func newGrid(size float64) *grid {
+	return &grid{size, map[[2]int][]entry{}}
+}
+
+// key returns the cell containing a location.
+func (g *grid) key(x, y float64) [2]int {
+	return [2]int{int(math.Floor(x / g.size)), int(math.Floor(y / g.size))}
+}
+
+// add adds a point to the grid.
+func (g *grid) add(x, y float64, index int) {
+	k := g.key(x, y)
+	g.cells[k] = append(g.cells[k], entry{x, y, index})
+}
+
+// near reports whether any point in the grid is closer than radius to a location.
+// Points for which skip returns true are ignored. skip can be nil.
+func (g *grid) near(x, y, radius float64, skip func(e entry) bool) bool {
+	min := g.key(x-radius, y-radius)
+	max := g.key(x+radius, y+radius)
+	r2 := radius * radius
+	for j := min[1]; j <= max[1]; j++ {
+		for i := min[0]; i <= max[0]; i++ {
+			for _, e := range g.cells[[2]int{i, j}] {
+				dx := e.x - x
+				dy := e.y - y
+				if dx*dx+dy*dy < r2 && (skip == nil || !skip(e)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+// Package flowfield traces lines through vector fields.
+package flowfield
+
+import (
+	"math"
+	"slices"
+
+	"github.com/bit101/bitlib/blmath"
+	"github.com/bit101/bitlib/geom"
+)
+
+// Integrator is the method used to step along a field.
+type Integrator int
+
+const (
+	// Euler takes a fixed step in the direction of the flow at each point. It is fast but drifts on curves.
+	Euler Integrator = iota
+	// RK4 is fourth order Runge-Kutta, which samples the field four times per step and follows curves closely.
+	RK4
+)
+
+// separationRatio is how close an evenly spaced streamline can get to another one, as a fraction of the separation.
+const separationRatio = 0.5
+
+// Tracer traces streamlines through a field.
+type Tracer struct {
+	// Field is the field to trace.
+	Field *Field
+	// StepSize is the distance moved each step. It must be greater than 0, or no lines are traced.
+	StepSize float64
+	// MaxSteps is the most steps taken in each direction.
+	MaxSteps int
+	// Integrator is the method used to step along the field.
+	Integrator Integrator
+	// Bounds stops lines that leave it. nil means unbounded.
+	Bounds *geom.Rect
+	// Bidirectional traces backwards from the start point as well as forwards.
+	Bidirectional bool
+	// Separation avoids collisions when greater than 0. Lines stop when they come within this distance
+	// of a line traced earlier, or of an earlier part of themselves.
+	Separation float64
+
+	grid *grid
+}
+
+// NewTracer creates a tracer for a field, stepping 1 unit at a time for up to 1000 steps using RK4.
+func NewTracer(field *Field) *Tracer {
+	return &Tracer{
+		Field:      field,
+		StepSize:   1,
+		MaxSteps:   1000,
+		Integrator: RK4,
+	}
+}
+
+// Reset forgets previously traced lines, so new lines won't avoid them.
+func (t *Tracer) Reset() {
+	t.grid = nil
+}
+
+// Trace traces a single streamline from the given location.
+// It returns nil if the start point is out of bounds, too close to an earlier line when avoiding collisions,
+// or if StepSize is 0 or less.
+func (t *Tracer) Trace(x, y float64) geom.PointList {
+	if !(t.StepSize > 0) || !t.inBounds(x, y) {
+		return nil
+	}
+	if t.Separation > 0 {
+		if t.grid == nil {
+			t.grid = newGrid(t.Separation)
+		}
+		if t.grid.near(x, y, t.Separation, nil) {
+			return nil
+		}
+	}
+
+	// the line's own points are kept in their own grid so it can avoid itself.
+	// indices run forwards and backwards from the start point.
+	self := newGrid(math.Max(t.Separation, t.StepSize))
+	self.add(x, y, 0)
+	points := t.walk(x, y, 1, self)
+	if t.Bidirectional {
+		back := t.walk(x, y, -1, self)
+		slices.Reverse(back)
+		points = append(back[:len(back)-1], points...)
+	}
+	if len(points) < 2 {
+		return nil
+	}
+	if t.grid != nil {
+		for _, p := range points {
+			t.grid.add(p.X, p.Y, 0)
+		}
+	}
+	return points
+}
+
+// TraceAll traces a streamline from each point in a list, leaving out any that return nil.
+func (t *Tracer) TraceAll(points geom.PointList) []geom.PointList {
+	lines := []geom.PointList{}
+	for _, p := range points {
+		if line := t.Trace(p.X, p.Y); line != nil {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// EvenlySpaced fills bounds with streamlines that are roughly separation apart, using the Jobard-Lefer method.
+// New lines are started at separation from the sides of existing lines and stop when they get within
+// half of separation of another line. The tracer's field, step size, max steps and integrator are used.
+// Lines traced by the tracer itself aren't affected.
+// It returns nil if separation or the tracer's StepSize is 0 or less.
+func (t *Tracer) EvenlySpaced(bounds *geom.Rect, separation float64) []geom.PointList {
+	if !(separation > 0) || !(t.StepSize > 0) {
+		return nil
+	}
+	tracer := &Tracer{
+		Field:         t.Field,
+		StepSize:      t.StepSize,
+		MaxSteps:      t.MaxSteps,
+		Integrator:    t.Integrator,
+		Bounds:        bounds,
+		Bidirectional: true,
+		Separation:    separation * separationRatio,
+		grid:          newGrid(separation),
+	}
+	lines := []geom.PointList{}
+	queue := []geom.PointList{}
+
+	try := func(x, y float64) {
+		if !tracer.inBounds(x, y) || tracer.grid.near(x, y, separation, nil) {
+			return
+		}
+		if line := tracer.Trace(x, y); line != nil {
+			lines = append(lines, line)
+			queue = append(queue, line)
+		}
+	}
+
+	// seed new lines from the sides of existing ones until there is no room left.
+	drain := func() {
+		for len(queue) > 0 {
+			line := queue[0]
+			queue = queue[1:]
+			for i, p := range line {
+				a := line[max(i-1, 0)]
+				b := line[min(i+1, len(line)-1)]
+				dx := b.X - a.X
+				dy := b.Y - a.Y
+				l := math.Hypot(dx, dy)
+				if l == 0 {
+					continue
+				}
+				nx := -dy / l * separation
+				ny := dx / l * separation
+				try(p.X+nx, p.Y+ny)
+				try(p.X-nx, p.Y-ny)
+			}
+		}
+	}
+
+	try(bounds.X+bounds.W/2, bounds.Y+bounds.H/2)
+	drain()
+	// areas that the first lines can't reach, such as the other side of a sink, get seeded from a grid.
+	for y := bounds.Y + separation/2; y < bounds.Y+bounds.H; y += separation {
+		for x := bounds.X + separation/2; x < bounds.X+bounds.W; x += separation {
+			try(x, y)
+			drain()
+		}
+	}
+	return lines
+}
+
+// walk steps along the field from a location in the given direction, 1 for forwards or -1 for backwards.
+// The returned points start with the start location.
+func (t *Tracer) walk(x, y, dir float64, self *grid) geom.PointList {
+	points := geom.PointList{geom.NewPoint(x, y)}
+	sign := int(dir)
+	// points on the line this many steps away are always close, so they are not counted as collisions.
+	skip := int(math.Ceil(t.Separation/t.StepSize)) + 1
+	for i := 1; i <= t.MaxSteps; i++ {
+		nx, ny, ok := t.step(x, y, dir)
+		if !ok || !t.inBounds(nx, ny) {
+			break
+		}
+		if t.Separation > 0 {
+			if t.grid.near(nx, ny, t.Separation, nil) {
+				break
+			}
+			index := i * sign
+			if self.near(nx, ny, t.Separation, func(e entry) bool {
+				return blmath.Abs(e.index-index) <= skip
+			}) {
+				break
+			}
+			self.add(nx, ny, index)
+		}
+		x, y = nx, ny
+		points.AddXY(x, y)
+	}
+	return points
+}
+
+// step moves one step along the field from a location, returning false if the flow is zero.
+func (t *Tracer) step(x, y, dir float64) (float64, float64, bool) {
+	h := t.StepSize * dir
+	k1x, k1y, ok := t.Field.direction(x, y)
+	if !ok {
+		return 0, 0, false
+	}
+	if t.Integrator == Euler {
+		return x + k1x*h, y + k1y*h, true
+	}
+	k2x, k2y, ok2 := t.Field.direction(x+k1x*h/2, y+k1y*h/2)
+	k3x, k3y, ok3 := t.Field.direction(x+k2x*h/2, y+k2y*h/2)
+	k4x, k4y, ok4 := t.Field.direction(x+k3x*h, y+k3y*h)
+	if !ok2 || !ok3 || !ok4 {
+		return 0, 0, false
+	}
+	return x + (k1x+2*k2x+2*k3x+k4x)*h/6, y + (k1y+2*k2y+2*k3y+k4y)*h/6, true
+}
+
+// inBounds reports whether a location is inside the tracer's bounds.
+func (t *Tracer) inBounds(x, y float64) bool {
+	if t.Bounds == nil {
+		return true
+	}
+	return geom.PointInRect(x, y, t.Bounds.X, t.Bounds.Y, t.Bounds.W, t.Bounds.H)
+}
@@ -0,0 +1,518 @@
+// Package geom has geometry related structs and funcs.
+package geom
+
+import (
+	"math"
+	"sort"
+)
+
+// BooleanOp is a way of combining two sets of polygons.
+type BooleanOp int
+
+const (
+	// Union is the area inside either set.
+	Union BooleanOp = iota
+	// Intersection is the area inside both sets.
+	Intersection
+	// Difference is the area inside the subject but not the clip.
+	Difference
+	// Xor is the area inside one set or the other, but not both.
+	Xor
+)
+
+// FillRule decides what is inside a set of polygons that overlap or cross themselves.
+type FillRule int
+
+const (
+	// EvenOdd counts an area as inside if it is surrounded an odd number of times.
+	// Holes can go in either direction.
+	EvenOdd FillRule = iota
+	// NonZero counts an area as inside unless the edges around it go both ways equally.
+	// Holes have to go in the opposite direction to the polygon they are in.
+	NonZero
+	// Positive counts an area as inside if more edges around it go clockwise on screen than counterclockwise.
+	// Areas only surrounded by counterclockwise rings are left out.
+	Positive
+)
+
+// filled returns whether an area with the given winding number is inside.
+func (f FillRule) filled(winding int) bool {
+	switch f {
+	case NonZero:
+		return winding != 0
+	case Positive:
+		return winding > 0
+	}
+	return winding%2 != 0
+}
+
+// Polygon is a ring of points made by a polygon operation.
+// The last point connects back to the first and is not repeated.
+type Polygon struct {
+	Points PointList
+	// Hole is true if the ring is a hole in another polygon.
+	// Outer rings have a positive SignedArea, so go clockwise on screen, and holes go counterclockwise.
+	Hole bool
+}
+
+// PolygonUnion returns the area inside either set of polygons, using the EvenOdd rule.
+func PolygonUnion(subject, clip []PointList) []*Polygon {
+	return PolygonBoolean(Union, subject, clip, EvenOdd)
+}
+
+// PolygonIntersection returns the area inside both sets of polygons, using the EvenOdd rule.
+func PolygonIntersection(subject, clip []PointList) []*Polygon {
+	return PolygonBoolean(Intersection, subject, clip, EvenOdd)
+}
+
+// PolygonDifference returns the area inside the subject polygons but not the clip polygons, using the EvenOdd rule.
+func PolygonDifference(subject, clip []PointList) []*Polygon {
+	return PolygonBoolean(Difference, subject, clip, EvenOdd)
+}
+
+// PolygonXor returns the area inside one set of polygons or the other, but not both, using the EvenOdd rule.
+func PolygonXor(subject, clip []PointList) []*Polygon {
+	return PolygonBoolean(Xor, subject, clip, EvenOdd)
+}
+
+// PolygonBoolean combines two sets of polygons. Each set is a list of rings, which can be concave,
+// cross themselves and each other, and include holes. The fill rule decides what is inside each set.
+// A Union with no clip polygons cleans up a single set, resolving any self intersections.
+func PolygonBoolean(op BooleanOp, subject, clip []PointList, rule FillRule) []*Polygon {
+	return newArrangement(subject, clip).polygons(func(winding [2]int) bool {
+		s, c := rule.filled(winding[0]), rule.filled(winding[1])
+		switch op {
+		case Intersection:
+			return s && c
+		case Difference:
+			return s && !c
+		case Xor:
+			return s != c
+		}
+		return s || c
+	})
+}
+
+//////////////////////////////
+// Arrangement
+//////////////////////////////
+
+// arrangement holds the edges of two sets of polygons, split wherever they touch or cross,
+// so that no two edges cross and each edge is stored once.
+type arrangement struct {
+	eps   float64
+	nodes *nodeFinder
+	edges []arrangementEdge
+	bands [2]*edgeBands
+}
+
+// arrangementEdge is an edge between two nodes, with u < v.
+type arrangementEdge struct {
+	u, v int
+	// winding is how much the winding number of each set goes up crossing the edge from its right to its left.
+	winding [2]int
+}
+
+// inputEdge is an edge of one of the polygons, with the points it needs to be split at.
+type inputEdge struct {
+	a, b       *Point
+	set        int
+	minX, maxX float64
+	splits     PointList
+}
+
+// newArrangement creates an arrangement from two sets of polygons.
+func newArrangement(sets ...[]PointList) *arrangement {
+	input := []*inputEdge{}
+	all := NewPointList()
+	for set, rings := range sets {
+		for _, ring := range rings {
+			if len(ring) > 1 && ring.First().X == ring.Last().X && ring.First().Y == ring.Last().Y {
+				ring = ring[:len(ring)-1]
+			}
+			if len(ring) < 3 {
+				continue
+			}
+			for i, a := range ring {
+				b := ring[(i+1)%len(ring)]
+				if a.X == b.X && a.Y == b.Y {
+					continue
+				}
+				input = append(input, &inputEdge{a: a, b: b, set: set, minX: math.Min(a.X, b.X), maxX: math.Max(a.X, b.X)})
+				all.Add(a)
+			}
+		}
+	}
+	arr := &arrangement{eps: snapTolerance(all)}
+	arr.nodes = newNodeFinder(arr.eps)
+	arr.split(input)
+
+	index := map[[2]int]int{}
+	for _, e := range input {
+		sort.Slice(e.splits, func(i, j int) bool { return along(e.a, e.b, e.splits[i]) < along(e.a, e.b, e.splits[j]) })
+		points := append(append(PointList{e.a}, e.splits...), e.b)
+		prev := arr.nodes.find(points[0])
+		for _, p := range points[1:] {
+			node := arr.nodes.find(p)
+			if node == prev {
+				continue
+			}
+			key, sign := [2]int{prev, node}, 1
+			if node < prev {
+				key, sign = [2]int{node, prev}, -1
+			}
+			i, ok := index[key]
+			if !ok {
+				i = len(arr.edges)
+				index[key] = i
+				arr.edges = append(arr.edges, arrangementEdge{u: key[0], v: key[1]})
+			}
+			arr.edges[i].winding[e.set] += sign
+			prev = node
+		}
+	}
+
+	// edges that cancel out don't change the winding, so can't be a boundary.
+	edges := arr.edges[:0]
+	for _, e := range arr.edges {
+		if e.winding != [2]int{} {
+			edges = append(edges, e)
+		}
+	}
+	arr.edges = edges
+	return arr
+}
+
+// snapTolerance returns how close points need to be to count as the same, based on their spread.
+func snapTolerance(points PointList) float64 {
+	if len(points) == 0 {
+		return 1e-10
+	}
+	box := points.BoundingBox()
+	size := math.Max(math.Max(box.W, box.H), math.Max(math.Abs(box.X), math.Abs(box.Y)))
+	if !(size > 0) || math.IsInf(size, 1) {
+		return 1e-10
+	}
+	return size * 1e-10
+}
+
+// split finds where edges touch or cross and records the split points on each edge.
+func (a *arrangement) split(edges []*inputEdge) {
+	sorted := append([]*inputEdge{}, edges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].minX < sorted[j].minX })
+	for i, e := range sorted {
+		eMinY, eMaxY := math.Min(e.a.Y, e.b.Y), math.Max(e.a.Y, e.b.Y)
+		for _, f := range sorted[i+1:] {
+			if f.minX > e.maxX+a.eps {
+				break
+			}
+			if math.Max(f.a.Y, f.b.Y) < eMinY-a.eps || math.Min(f.a.Y, f.b.Y) > eMaxY+a.eps {
+				continue
+			}
+			a.intersect(e, f)
+		}
+	}
+}
+
+// intersect adds split points to two edges where they touch or cross.
+func (a *arrangement) intersect(e, f *inputEdge) {
+	for _, p := range []*Point{f.a, f.b} {
+		if a.touches(e.a, e.b, p) {
+			e.splits.Add(p)
+		}
+	}
+	for _, p := range []*Point{e.a, e.b} {
+		if a.touches(f.a, f.b, p) {
+			f.splits.Add(p)
+		}
+	}
+	if p, ok := a.crossing(e.a, e.b, f.a, f.b); ok {
+		e.splits.Add(p)
+		f.splits.Add(p)
+	}
+}
+
+// touches returns whether a point lies on the inside of the segment p0 -> p1, away from its ends.
+func (a *arrangement) touches(p0, p1, p *Point) bool {
+	t := along(p0, p1, p)
+	if t <= 0 || t >= 1 || p.Distance(p0) <= a.eps || p.Distance(p1) <= a.eps {
+		return false
+	}
+	return math.Abs(lineDistance(p0, p1, p)) <= a.eps
+}
+
+// crossing returns where the segments p0 -> p1 and p2 -> p3 cross, if each has an end clearly on either side of the other.
+// Segments that only just touch are handled by touches.
+func (a *arrangement) crossing(p0, p1, p2, p3 *Point) (*Point, bool) {
+	d0, d1 := lineDistance(p2, p3, p0), lineDistance(p2, p3, p1)
+	d2, d3 := lineDistance(p0, p1, p2), lineDistance(p0, p1, p3)
+	if !a.straddles(d0, d1) || !a.straddles(d2, d3) {
+		return nil, false
+	}
+	t := d0 / (d0 - d1)
+	return NewPoint(p0.X+(p1.X-p0.X)*t, p0.Y+(p1.Y-p0.Y)*t), true
+}
+
+// straddles returns whether two distances from a line are clearly on opposite sides of it.
+func (a *arrangement) straddles(d0, d1 float64) bool {
+	return (d0 > a.eps && d1 < -a.eps) || (d0 < -a.eps && d1 > a.eps)
+}
+
+// along returns how far a point is along the segment p0 -> p1, from 0 at p0 to 1 at p1.
+func along(p0, p1, p *Point) float64 {
+	dx, dy := p1.X-p0.X, p1.Y-p0.Y
+	return ((p.X-p0.X)*dx + (p.Y-p0.Y)*dy) / (dx*dx + dy*dy)
+}
+
+// lineDistance returns the signed distance of a point from the line through p0 and p1.
+func lineDistance(p0, p1, p *Point) float64 {
+	dx, dy := p1.X-p0.X, p1.Y-p0.Y
+	return (dx*(p.Y-p0.Y) - dy*(p.X-p0.X)) / math.Hypot(dx, dy)
+}
+
+//////////////////////////////
+// Winding
+//////////////////////////////
+
+// sides returns the winding numbers of each set on the left and right of an edge.
+func (a *arrangement) sides(index int) ([2]int, [2]int) {
+	e := a.edges[index]
+	u, v := a.nodes.points[e.u], a.nodes.points[e.v]
+	dx, dy := v.X-u.X, v.Y-u.Y
+	mid := NewPoint(u.X+dx/2, u.Y+dy/2)
+	// cast the ray across the edge rather than along it.
+	axis, left := 0, dy < 0
+	if math.Abs(dx) > math.Abs(dy) {
+		axis, left = 1, dx > 0
+	}
+	// this is the winding just past the edge along the ray.
+	w := a.windingAt(mid, axis, index)
+	if left {
+		return w, [2]int{w[0] - e.winding[0], w[1] - e.winding[1]}
+	}
+	return [2]int{w[0] + e.winding[0], w[1] + e.winding[1]}, w
+}
+
+// windingAt returns the winding number of each set at a point, casting a ray along the x axis, or the y axis if axis is 1.
+// The edge at skip is left out.
+func (a *arrangement) windingAt(p *Point, axis, skip int) [2]int {
+	if a.bands[axis] == nil {
+		a.bands[axis] = newEdgeBands(a, axis)
+	}
+	var w [2]int
+	coords := func(q *Point) (float64, float64) {
+		if axis == 1 {
+			return q.Y, q.X
+		}
+		return q.X, q.Y
+	}
+	px, py := coords(p)
+	for _, be := range a.bands[axis].at(py) {
+		// the rest of the band is behind the ray.
+		if be.reach < px {
+			break
+		}
+		if be.index == skip {
+			continue
+		}
+		e := a.edges[be.index]
+		ux, uy := coords(a.nodes.points[e.u])
+		vx, vy := coords(a.nodes.points[e.v])
+		side := (vx-ux)*(py-uy) - (px-ux)*(vy-uy)
+		if uy <= py && vy > py && side > 0 {
+			w[0] += e.winding[0]
+			w[1] += e.winding[1]
+		} else if vy <= py && uy > py && side < 0 {
+			w[0] -= e.winding[0]
+			w[1] -= e.winding[1]
+		}
+	}
+	// swapping x and y flips the direction of every edge.
+	if axis == 1 {
+		w[0], w[1] = -w[0], -w[1]
+	}
+	return w
+}
+
+// edgeBands sorts edges into bands across one axis, so a ray only needs to check the edges in its band.
+type edgeBands struct {
+	min, size float64
+	bands     [][]bandEdge
+}
+
+// bandEdge is an edge in a band, with how far it reaches along the ray.
+type bandEdge struct {
+	index int
+	reach float64
+}
+
+// newEdgeBands creates bands of y values for rays along x, or x values for rays along y if axis is 1.
+// Each band is sorted by reach, furthest first.
+func newEdgeBands(a *arrangement, axis int) *edgeBands {
+	coords := func(p *Point) (float64, float64) {
+		if axis == 1 {
+			return p.Y, p.X
+		}
+		return p.X, p.Y
+	}
+	b := &edgeBands{min: math.Inf(1)}
+	max := math.Inf(-1)
+	for _, p := range a.nodes.points {
+		_, c := coords(p)
+		b.min = math.Min(b.min, c)
+		max = math.Max(max, c)
+	}
+	count := min(len(a.edges)/8, 4096) + 1
+	b.size = (max - b.min) / float64(count)
+	if b.size == 0 {
+		b.size = 1
+	}
+	b.bands = make([][]bandEdge, count)
+	for i, e := range a.edges {
+		x0, c0 := coords(a.nodes.points[e.u])
+		x1, c1 := coords(a.nodes.points[e.v])
+		for band := b.band(math.Min(c0, c1)); band <= b.band(math.Max(c0, c1)); band++ {
+			b.bands[band] = append(b.bands[band], bandEdge{i, math.Max(x0, x1)})
+		}
+	}
+	for _, band := range b.bands {
+		sort.Slice(band, func(i, j int) bool { return band[i].reach > band[j].reach })
+	}
+	return b
+}
+
+// band returns the band a value falls in.
+func (b *edgeBands) band(value float64) int {
+	return max(0, min(len(b.bands)-1, int((value-b.min)/b.size)))
+}
+
+// at returns the edges in the band a value falls in.
+func (b *edgeBands) at(value float64) []bandEdge {
+	return b.bands[b.band(value)]
+}
+
+//////////////////////////////
+// Result
+//////////////////////////////
+
+// polygons returns the rings around the areas where inside is true for the winding numbers.
+func (a *arrangement) polygons(inside func([2]int) bool) []*Polygon {
+	// boundary edges go from, to with the inside on their left.
+	type boundary struct{ from, to int }
+	edges := []boundary{}
+	out := map[int][]int{}
+	for i, e := range a.edges {
+		left, right := a.sides(i)
+		l, r := inside(left), inside(right)
+		if l == r {
+			continue
+		}
+		b := boundary{e.u, e.v}
+		if r {
+			b = boundary{e.v, e.u}
+		}
+		out[b.from] = append(out[b.from], len(edges))
+		edges = append(edges, b)
+	}
+
+	// next returns the boundary edge that keeps the same area on the left, turning as far right as possible.
+	next := func(current int) int {
+		b := edges[current]
+		p := a.nodes.points[b.to]
+		from := a.nodes.points[b.from]
+		back := math.Atan2(from.Y-p.Y, from.X-p.X)
+		best, bestTurn := -1, math.Inf(1)
+		for _, i := range out[b.to] {
+			q := a.nodes.points[edges[i].to]
+			turn := back - math.Atan2(q.Y-p.Y, q.X-p.X)
+			for turn <= 0 {
+				turn += 2 * math.Pi
+			}
+			if turn < bestTurn {
+				best, bestTurn = i, turn
+			}
+		}
+		return best
+	}
+
+	polygons := []*Polygon{}
+	used := make([]bool, len(edges))
+	for start := range edges {
+		if used[start] {
+			continue
+		}
+		ring := NewPointList()
+		for i := start; i >= 0 && !used[i]; i = next(i) {
+			used[i] = true
+			p := a.nodes.points[edges[i].from]
+			ring.AddXY(p.X, p.Y)
+		}
+		ring = a.simplify(ring)
+		area := ring.SignedArea()
+		if len(ring) < 3 || math.Abs(area) <= a.eps*a.eps {
+			continue
+		}
+		polygons = append(polygons, &Polygon{Points: ring, Hole: area < 0})
+	}
+	return polygons
+}
+
+// simplify removes points from a ring that lie on a straight line between their neighbors.
+func (a *arrangement) simplify(ring PointList) PointList {
+	for changed := true; changed && len(ring) >= 3; {
+		changed = false
+		result := NewPointList()
+		for i, p := range ring {
+			prev := ring[(i+len(ring)-1)%len(ring)]
+			if len(result) > 0 {
+				prev = result.Last()
+			}
+			next := ring[(i+1)%len(ring)]
+			if math.Abs(lineDistance(prev, next, p)) <= a.eps {
+				changed = true
+				continue
+			}
+			result.Add(p)
+		}
+		ring = result
+	}
+	return ring
+}
+
+//////////////////////////////
+// Node finder
+//////////////////////////////
+
+// nodeFinder merges points that are within a tolerance of each other into numbered nodes.
+type nodeFinder struct {
+	size   float64
+	cells  map[[2]int][]int
+	points PointList
+}
+
+// newNodeFinder creates a new nodeFinder.
+func newNodeFinder(tolerance float64) *nodeFinder {
+	return &nodeFinder{
+		size:  tolerance,
+		cells: map[[2]int][]int{},
+	}
+}
+
+// find returns the node for a point, adding a new one if no node is within tolerance.
+func (n *nodeFinder) find(p *Point) int {
+	cx := int(math.Floor(p.X / n.size))
+	cy := int(math.Floor(p.Y / n.size))
+	for x := cx - 1; x <= cx+1; x++ {
+		for y := cy - 1; y <= cy+1; y++ {
+			for _, node := range n.cells[[2]int{x, y}] {
+				if n.points[node].Distance(p) <= n.size {
+					return node
+				}
+			}
+		}
+	}
+	node := len(n.points)
+	n.points.Add(p)
+	n.cells[[2]int{cx, cy}] = append(n.cells[[2]int{cx, cy}], node)
+	return node
+}
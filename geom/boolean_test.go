@@ -0,0 +1,96 @@
+// Package geom has geometry related structs and funcs.
+package geom
+
+import (
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+// square returns a square ring, clockwise on screen.
+func square(x, y, size float64) PointList {
+	return PointList{NewPoint(x, y), NewPoint(x+size, y), NewPoint(x+size, y+size), NewPoint(x, y+size)}
+}
+
+// totalArea returns the sum of the signed areas of a list of polygons.
+func totalArea(polygons []*Polygon) float64 {
+	area := 0.0
+	for _, p := range polygons {
+		area += p.Points.SignedArea()
+	}
+	return area
+}
+
+func TestSignedArea(t *testing.T) {
+	ring := square(0, 0, 10)
+	area := ring.SignedArea()
+	if area != 100 {
+		t.Errorf("Expected %f, got %f\n", 100.0, area)
+	}
+	reversed := PointList{ring[3], ring[2], ring[1], ring[0]}
+	area = reversed.SignedArea()
+	if area != -100 {
+		t.Errorf("Expected %f, got %f\n", -100.0, area)
+	}
+}
+
+func TestPolygonBoolean(t *testing.T) {
+	type test struct {
+		op       BooleanOp
+		rings    int
+		holes    int
+		expected float64
+	}
+	// a 10x10 square overlapping half of another, which has a 2x2 hole across the edge of the overlap.
+	subject := []PointList{square(0, 0, 10), square(4, 4, 2)}
+	clip := []PointList{square(5, 0, 10)}
+	tests := []test{
+		{Union, 2, 1, 150 - 2},
+		{Intersection, 1, 0, 50 - 2},
+		{Difference, 1, 0, 50 - 2},
+		{Xor, 3, 0, 100},
+	}
+	for _, test := range tests {
+		result := PolygonBoolean(test.op, subject, clip, EvenOdd)
+		holes := 0
+		for _, p := range result {
+			if p.Hole {
+				holes++
+			}
+			if p.Hole != (p.Points.SignedArea() < 0) {
+				t.Errorf("Expected hole to match orientation, got %v\n", p)
+			}
+		}
+		if len(result) != test.rings || holes != test.holes {
+			t.Errorf("Expected %d rings and %d holes, got %d and %d\n", test.rings, test.holes, len(result), holes)
+		}
+		area := totalArea(result)
+		if !blmath.Equalish(area, test.expected, 1e-9) {
+			t.Errorf("Expected %f, got %f\n", test.expected, area)
+		}
+	}
+}
+
+func TestPolygonBooleanFillRule(t *testing.T) {
+	// two squares drawn the same way, overlapping by 5x10.
+	rings := []PointList{square(0, 0, 10), square(5, 0, 10)}
+	area := totalArea(PolygonBoolean(Union, rings, nil, NonZero))
+	if !blmath.Equalish(area, 150, 1e-9) {
+		t.Errorf("Expected %f, got %f\n", 150.0, area)
+	}
+	area = totalArea(PolygonBoolean(Union, rings, nil, EvenOdd))
+	if !blmath.Equalish(area, 100, 1e-9) {
+		t.Errorf("Expected %f, got %f\n", 100.0, area)
+	}
+
+	// a bow tie, which crosses itself in the middle.
+	bowtie := PointList{NewPoint(0, 0), NewPoint(10, 10), NewPoint(10, 0), NewPoint(0, 10)}
+	result := PolygonUnion([]PointList{bowtie}, nil)
+	if len(result) != 2 {
+		t.Errorf("Expected %d rings, got %d\n", 2, len(result))
+	}
+	area = totalArea(result)
+	if !blmath.Equalish(area, 50, 1e-9) {
+		t.Errorf("Expected %f, got %f\n", 50.0, area)
+	}
+}
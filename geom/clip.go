@@ -0,0 +1,223 @@
+// Package geom has geometry related structs and funcs.
+package geom
+
+import (
+	"math"
+	"sort"
+)
+
+// ClipSegments cuts each segment where it crosses the edges of a set of polygons,
+// and returns the pieces inside the polygons and the pieces outside, like a stencil.
+// The rings are read with the EvenOdd rule, so they can be concave and include holes.
+// Pieces that run exactly along an edge can land on either side.
+func ClipSegments(segments SegmentList, rings []PointList) (SegmentList, SegmentList) {
+	c := newClipper(rings)
+	inside, outside := NewSegmentList(), NewSegmentList()
+	for _, s := range segments {
+		cuts, in := c.cut(s.PointA, s.PointB)
+		for i, isInside := range in {
+			piece := NewSegmentFromPoints(cuts[i], cuts[i+1])
+			if isInside {
+				inside.Add(piece)
+			} else {
+				outside.Add(piece)
+			}
+		}
+	}
+	return inside, outside
+}
+
+// ClipPolyline cuts an open polyline where it crosses the edges of a set of polygons,
+// and returns the runs inside the polygons and the runs outside.
+// The rings are read with the EvenOdd rule, so they can be concave and include holes.
+func ClipPolyline(path PointList, rings []PointList) ([]PointList, []PointList) {
+	return ClipPolylines([]PointList{path}, rings)
+}
+
+// ClipPolylines cuts a list of open polylines where they cross the edges of a set of polygons,
+// and returns the runs inside the polygons and the runs outside.
+// The rings are read with the EvenOdd rule, so they can be concave and include holes.
+func ClipPolylines(paths []PointList, rings []PointList) ([]PointList, []PointList) {
+	c := newClipper(rings)
+	inside, outside := []PointList{}, []PointList{}
+	for _, path := range paths {
+		var run PointList
+		runInside := false
+		end := func() {
+			if len(run) < 2 {
+				return
+			}
+			if runInside {
+				inside = append(inside, run)
+			} else {
+				outside = append(outside, run)
+			}
+		}
+		for i := 0; i < len(path)-1; i++ {
+			cuts, in := c.cut(path[i], path[i+1])
+			for j, isInside := range in {
+				if run == nil || isInside != runInside {
+					end()
+					run = PointList{NewPoint(cuts[j].X, cuts[j].Y)}
+					runInside = isInside
+				}
+				run.Add(cuts[j+1])
+			}
+		}
+		end()
+	}
+	return inside, outside
+}
+
+//////////////////////////////
+// Clipper
+//////////////////////////////
+
+// clipper finds where lines cross a set of polygons, using a grid of polygon edges.
+type clipper struct {
+	arr        *arrangement
+	minX, minY float64
+	size       float64
+	cols, rows int
+	cells      [][]int
+	// seen marks edges already checked for the current line.
+	seen []int
+	line int
+}
+
+// newClipper creates a new clipper for a set of polygons.
+func newClipper(rings []PointList) *clipper {
+	arr := newArrangement(rings)
+	c := &clipper{arr: arr, size: 1, seen: make([]int, len(arr.edges))}
+	if len(arr.edges) == 0 {
+		return c
+	}
+	box := arr.nodes.points.BoundingBox()
+	c.minX, c.minY = box.X, box.Y
+	// aim for about one edge per cell.
+	c.size = math.Max(math.Sqrt(box.W*box.H/float64(len(arr.edges))), math.Max(box.W, box.H)/float64(len(arr.edges)))
+	if c.size == 0 {
+		c.size = 1
+	}
+	c.cols = int(box.W/c.size) + 1
+	c.rows = int(box.H/c.size) + 1
+	c.cells = make([][]int, c.cols*c.rows)
+	for i, e := range arr.edges {
+		u, v := arr.nodes.points[e.u], arr.nodes.points[e.v]
+		x0, y0 := c.cell(math.Min(u.X, v.X), math.Min(u.Y, v.Y))
+		x1, y1 := c.cell(math.Max(u.X, v.X), math.Max(u.Y, v.Y))
+		for x := x0; x <= x1; x++ {
+			for y := y0; y <= y1; y++ {
+				c.cells[x+y*c.cols] = append(c.cells[x+y*c.cols], i)
+			}
+		}
+	}
+	return c
+}
+
+// cell returns the column and row of the cell a point falls in, clamped to the grid.
+func (c *clipper) cell(x, y float64) (int, int) {
+	col := max(0, min(c.cols-1, int(math.Floor((x-c.minX)/c.size))))
+	row := max(0, min(c.rows-1, int(math.Floor((y-c.minY)/c.size))))
+	return col, row
+}
+
+// nearby calls visit once for each polygon edge in the cells that the segment a -> b passes through.
+func (c *clipper) nearby(a, b *Point, visit func(int)) {
+	if len(c.cells) == 0 {
+		return
+	}
+	c.line++
+	eps := c.arr.eps
+	minX, maxX := math.Min(a.X, b.X)-eps, math.Max(a.X, b.X)+eps
+	col0, _ := c.cell(minX, 0)
+	col1, _ := c.cell(maxX, 0)
+	for col := col0; col <= col1; col++ {
+		// the part of the segment in this column.
+		x0 := math.Max(minX, c.minX+float64(col)*c.size)
+		x1 := math.Min(maxX, c.minX+float64(col+1)*c.size)
+		y0, y1 := math.Min(a.Y, b.Y), math.Max(a.Y, b.Y)
+		if a.X != b.X {
+			ya := a.Y + (b.Y-a.Y)*(x0-a.X)/(b.X-a.X)
+			yb := a.Y + (b.Y-a.Y)*(x1-a.X)/(b.X-a.X)
+			y0 = math.Max(y0, math.Min(ya, yb))
+			y1 = math.Min(y1, math.Max(ya, yb))
+		}
+		_, row0 := c.cell(0, y0-eps)
+		_, row1 := c.cell(0, y1+eps)
+		for row := row0; row <= row1; row++ {
+			for _, i := range c.cells[col+row*c.cols] {
+				if c.seen[i] != c.line {
+					c.seen[i] = c.line
+					visit(i)
+				}
+			}
+		}
+	}
+}
+
+// cut splits the segment a -> b where it crosses the polygon edges.
+// It returns the points along the segment, from a to b, and whether each piece between them is inside.
+// Pieces on the same side next to each other are joined.
+func (c *clipper) cut(a, b *Point) (PointList, []bool) {
+	length := a.Distance(b)
+	// copies, so that changing the results doesn't change the lines they came from.
+	a, b = NewPoint(a.X, a.Y), NewPoint(b.X, b.Y)
+	if len(c.arr.edges) == 0 || length <= c.arr.eps {
+		return PointList{a, b}, []bool{false}
+	}
+	eps := c.arr.eps
+	ts := []float64{0, 1}
+	c.nearby(a, b, func(i int) {
+		e := c.arr.edges[i]
+		u, v := c.arr.nodes.points[e.u], c.arr.nodes.points[e.v]
+		du, dv := lineDistance(a, b, u), lineDistance(a, b, v)
+		switch {
+		case math.Abs(du) <= eps && math.Abs(dv) <= eps:
+			// running along the edge, so cut at both its ends.
+			ts = append(ts, along(a, b, u), along(a, b, v))
+		case math.Abs(du) <= eps:
+			ts = append(ts, along(a, b, u))
+		case math.Abs(dv) <= eps:
+			ts = append(ts, along(a, b, v))
+		case (du < 0) != (dv < 0):
+			t := du / (du - dv)
+			ts = append(ts, along(a, b, NewPoint(u.X+(v.X-u.X)*t, u.Y+(v.Y-u.Y)*t)))
+		}
+	})
+	sort.Float64s(ts)
+
+	cuts := PointList{a}
+	in := []bool{}
+	prev := 0.0
+	for _, t := range ts {
+		if t <= prev || (t-prev)*length <= eps {
+			continue
+		}
+		if t >= 1 {
+			t = 1
+		}
+		// test the middle of the piece, which doesn't touch any edge.
+		mid := (prev + t) / 2
+		isInside := c.arr.windingAt(NewPoint(a.X+(b.X-a.X)*mid, a.Y+(b.Y-a.Y)*mid), 0, -1)[0]%2 != 0
+		p := b
+		if t < 1 {
+			p = NewPoint(a.X+(b.X-a.X)*t, a.Y+(b.Y-a.Y)*t)
+		}
+		if len(in) > 0 && in[len(in)-1] == isInside {
+			cuts[len(cuts)-1] = p
+		} else {
+			cuts.Add(p)
+			in = append(in, isInside)
+		}
+		prev = t
+		if t == 1 {
+			break
+		}
+	}
+	if len(in) == 0 {
+		return PointList{a, b}, []bool{false}
+	}
+	cuts[len(cuts)-1] = b
+	return cuts, in
+}
@@ -0,0 +1,77 @@
+// Package geom has geometry related structs and funcs.
+package geom
+
+import (
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+// totalLength returns the sum of the lengths of a list of segments.
+func totalLength(segments SegmentList) float64 {
+	length := 0.0
+	for _, s := range segments {
+		length += s.Length()
+	}
+	return length
+}
+
+func TestClipSegments(t *testing.T) {
+	type test struct {
+		segment         *Segment
+		inside, outside int
+		insideLength    float64
+		outsideLength   float64
+	}
+	// a 10x10 square with a 4x4 hole in the middle.
+	rings := []PointList{square(0, 0, 10), square(3, 3, 4)}
+	tests := []test{
+		// across the middle, through the hole.
+		{NewSegment(-5, 5, 15, 5), 2, 3, 6, 14},
+		// inside, above the hole.
+		{NewSegment(1, 1, 9, 1), 1, 0, 8, 0},
+		// outside.
+		{NewSegment(-5, -5, 15, -5), 0, 1, 0, 20},
+		// starting inside, ending outside.
+		{NewSegment(5, 1, 5, -4), 1, 1, 1, 4},
+	}
+	for _, test := range tests {
+		inside, outside := ClipSegments(SegmentList{test.segment}, rings)
+		if len(inside) != test.inside || len(outside) != test.outside {
+			t.Errorf("Expected %d inside and %d outside, got %d and %d\n", test.inside, test.outside, len(inside), len(outside))
+		}
+		length := totalLength(inside)
+		if !blmath.Equalish(length, test.insideLength, 1e-9) {
+			t.Errorf("Expected %f, got %f\n", test.insideLength, length)
+		}
+		length = totalLength(outside)
+		if !blmath.Equalish(length, test.outsideLength, 1e-9) {
+			t.Errorf("Expected %f, got %f\n", test.outsideLength, length)
+		}
+	}
+}
+
+func TestClipPolyline(t *testing.T) {
+	// a concave U shape.
+	ring := PointList{
+		NewPoint(0, 0), NewPoint(3, 0), NewPoint(3, 7), NewPoint(7, 7),
+		NewPoint(7, 0), NewPoint(10, 0), NewPoint(10, 10), NewPoint(0, 10),
+	}
+	path := PointList{NewPoint(-1, 5), NewPoint(11, 5), NewPoint(11, 8), NewPoint(5, 8)}
+	inside, outside := ClipPolyline(path, []PointList{ring})
+	if len(inside) != 3 || len(outside) != 3 {
+		t.Fatalf("Expected %d inside and %d outside, got %d and %d\n", 3, 3, len(inside), len(outside))
+	}
+	// the last outside run goes around the corner at 11, 5 and back in.
+	run := outside[2]
+	if len(run) != 4 || !run[2].Equals(NewPoint(11, 8)) {
+		t.Errorf("Expected run around the corner, got %v\n", run)
+	}
+	length := 0.0
+	for _, run := range inside {
+		length += run.Length()
+	}
+	if !blmath.Equalish(length, 3+3+5, 1e-9) {
+		t.Errorf("Expected %f, got %f\n", 11.0, length)
+	}
+}
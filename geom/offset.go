@@ -0,0 +1,161 @@
+// Package geom has geometry related structs and funcs.
+package geom
+
+import (
+	"math"
+)
+
+// JoinType is how the corners of an offset are filled in.
+type JoinType int
+
+const (
+	// MiterJoin extends the edges until they meet, beveling corners that would reach past the miter limit.
+	// Open polylines get butt ends.
+	MiterJoin JoinType = iota
+	// RoundJoin fills corners with an arc. Open polylines get round ends.
+	RoundJoin
+	// SquareJoin cuts corners off square, at the offset distance from the corner. Open polylines get square ends.
+	SquareJoin
+)
+
+// arcTolerance is how far a round join can stray from a true arc, as a fraction of the offset.
+const arcTolerance = 0.001
+
+// OffsetPolygons grows a set of polygons by delta, or shrinks them if delta is negative.
+// The rings are read with the EvenOdd rule, so they can cross themselves and include holes.
+// For miter joins, miterLimit is the furthest a corner can reach, as a multiple of delta. It is at least 1.
+func OffsetPolygons(rings []PointList, delta float64, join JoinType, miterLimit float64) []*Polygon {
+	if delta == 0 {
+		return PolygonUnion(rings, nil)
+	}
+	return PolygonBoolean(Union, offsetRings(rings, delta, join, miterLimit), nil, Positive)
+}
+
+// OffsetPolyline returns the outline around a polyline, delta out on each side.
+// The ends are shaped by the join type. Where the outline overlaps itself, it is merged.
+// A polyline whose last point is the same as its first is closed, so it has no ends and is outlined
+// on the inside and the outside.
+func OffsetPolyline(path PointList, delta float64, join JoinType, miterLimit float64) []*Polygon {
+	path = withoutRepeats(path)
+	if len(path) < 2 || delta == 0 {
+		return []*Polygon{}
+	}
+	delta = math.Abs(delta)
+	if len(path) > 3 && path.First().Equals(path.Last()) {
+		rings := []PointList{path[:len(path)-1]}
+		return PolygonBoolean(Difference, offsetRings(rings, delta, join, miterLimit), offsetRings(rings, -delta, join, miterLimit), Positive)
+	}
+	// going there and back makes a ring with no area, which the offset opens up.
+	ring := path.Clone()
+	for i := len(path) - 2; i > 0; i-- {
+		ring.Add(path[i])
+	}
+	return PolygonBoolean(Union, []PointList{offsetRing(ring, delta, join, miterLimit)}, nil, Positive)
+}
+
+// InsetPolygons shrinks a set of polygons by step, then by twice step, and so on until nothing is left.
+// It returns the polygons for each step, which can be used as fill rings for a plotter.
+func InsetPolygons(rings []PointList, step float64, join JoinType, miterLimit float64) [][]*Polygon {
+	insets := [][]*Polygon{}
+	if step <= 0 {
+		return insets
+	}
+	for i := 1; ; i++ {
+		inset := OffsetPolygons(rings, -step*float64(i), join, miterLimit)
+		if len(inset) == 0 {
+			return insets
+		}
+		insets = append(insets, inset)
+	}
+}
+
+// offsetRings cleans up a set of rings with the EvenOdd rule and offsets each one.
+// The results need a Positive union to clean them up.
+func offsetRings(rings []PointList, delta float64, join JoinType, miterLimit float64) []PointList {
+	raw := []PointList{}
+	for _, polygon := range PolygonUnion(rings, nil) {
+		raw = append(raw, offsetRing(polygon.Points, delta, join, miterLimit))
+	}
+	return raw
+}
+
+// offsetRing moves each edge of a ring delta to its right, joining the corners.
+// For rings with a positive SignedArea, the right is the outside.
+// The result can cross itself and needs a Positive union to clean it up.
+func offsetRing(ring PointList, delta float64, join JoinType, miterLimit float64) PointList {
+	ring = withoutRepeats(ring)
+	count := len(ring)
+	// normals[i] points right of the edge from ring[i] to the next point.
+	normals := make([]*Vector, count)
+	for i, p := range ring {
+		q := ring[(i+1)%count]
+		length := p.Distance(q)
+		normals[i] = NewVector((q.Y-p.Y)/length, -(q.X-p.X)/length)
+	}
+
+	result := NewPointList()
+	for i, p := range ring {
+		n0, n1 := normals[(i+count-1)%count], normals[i]
+		cross := n0.CrossProduct(n1)
+		dot := n0.DotProduct(n1)
+		switch {
+		case dot > 0 && math.Abs(cross) < 1e-12:
+			// straight on.
+			result.AddXY(p.X+n1.U*delta, p.Y+n1.V*delta)
+		case cross*delta < 0 && dot > -0.999:
+			// the offset edges overlap here, and the union trims them back.
+			result.AddXY(p.X+n0.U*delta, p.Y+n0.V*delta)
+			result.Add(p)
+			result.AddXY(p.X+n1.U*delta, p.Y+n1.V*delta)
+		default:
+			addJoin(&result, p, n0, n1, delta, join, miterLimit)
+		}
+	}
+	return result
+}
+
+// addJoin fills the gap around a corner between the offset edges on either side of it.
+func addJoin(result *PointList, p *Point, n0, n1 *Vector, delta float64, join JoinType, miterLimit float64) {
+	cross := n0.CrossProduct(n1)
+	dot := n0.DotProduct(n1)
+	// the turn from one offset edge to the other, going around the outside of the corner.
+	angle := math.Copysign(math.Atan2(math.Abs(cross), dot), delta)
+	start := math.Atan2(n0.V, n0.U)
+	switch join {
+	case RoundJoin:
+		steps := int(math.Ceil(math.Abs(angle) / (2 * math.Acos(1-arcTolerance))))
+		for i := 0; i <= steps; i++ {
+			a := start + angle*float64(i)/float64(steps)
+			result.AddXY(p.X+math.Cos(a)*delta, p.Y+math.Sin(a)*delta)
+		}
+	case SquareJoin:
+		// m points from the corner out to the middle of the square end.
+		a := start + angle/2
+		mx, my := math.Cos(a)*math.Copysign(1, delta), math.Sin(a)*math.Copysign(1, delta)
+		// slide along each offset edge, away from the corner, until it meets the square end.
+		for _, e := range [][4]float64{{n0.U, n0.V, -n0.V, n0.U}, {n1.U, n1.V, n1.V, -n1.U}} {
+			s := (math.Abs(delta) - delta*(e[0]*mx+e[1]*my)) / (e[2]*mx + e[3]*my)
+			result.AddXY(p.X+e[0]*delta+e[2]*s, p.Y+e[1]*delta+e[3]*s)
+		}
+	default:
+		// the miter reaches 1 / cos(half the turn) times delta from the corner.
+		if cross*delta > 0 && math.Sqrt(2/(1+dot)) <= math.Max(miterLimit, 1) {
+			scale := delta / (1 + dot)
+			result.AddXY(p.X+(n0.U+n1.U)*scale, p.Y+(n0.V+n1.V)*scale)
+			return
+		}
+		result.AddXY(p.X+n0.U*delta, p.Y+n0.V*delta)
+		result.AddXY(p.X+n1.U*delta, p.Y+n1.V*delta)
+	}
+}
+
+// withoutRepeats returns a ring or path without points that repeat the one before.
+func withoutRepeats(points PointList) PointList {
+	result := NewPointList()
+	for _, p := range points {
+		if len(result) == 0 || !p.Equals(result.Last()) {
+			result.Add(p)
+		}
+	}
+	return result
+}
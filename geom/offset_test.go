@@ -0,0 +1,104 @@
+// Package geom has geometry related structs and funcs.
+package geom
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+func TestOffsetPolygons(t *testing.T) {
+	type test struct {
+		join     JoinType
+		delta    float64
+		expected float64
+	}
+	tests := []test{
+		{MiterJoin, 1, 144},
+		{RoundJoin, 1, 140 + math.Pi},
+		{SquareJoin, 1, 144 - 4*(math.Sqrt2-1)*(math.Sqrt2-1)},
+		{MiterJoin, -1, 64},
+		{RoundJoin, -1, 64},
+		{SquareJoin, -4.9, 0.04},
+		{MiterJoin, -5, 0},
+	}
+	rings := []PointList{square(0, 0, 10)}
+	for _, test := range tests {
+		area := totalArea(OffsetPolygons(rings, test.delta, test.join, 2))
+		if !blmath.Equalish(area, test.expected, 0.01) {
+			t.Errorf("Expected %f, got %f\n", test.expected, area)
+		}
+	}
+
+	// growing a square with a hole shrinks the hole.
+	rings = []PointList{square(0, 0, 10), square(3, 3, 4)}
+	result := OffsetPolygons(rings, 1, MiterJoin, 2)
+	if len(result) != 2 || !result[0].Hole && !result[1].Hole {
+		t.Errorf("Expected a polygon with a hole, got %v\n", result)
+	}
+	area := totalArea(result)
+	if !blmath.Equalish(area, 144-4, 1e-9) {
+		t.Errorf("Expected %f, got %f\n", 140.0, area)
+	}
+}
+
+func TestOffsetPolyline(t *testing.T) {
+	type test struct {
+		join     JoinType
+		expected float64
+	}
+	tests := []test{
+		{MiterJoin, 20},
+		{RoundJoin, 20 + math.Pi},
+		{SquareJoin, 24},
+	}
+	path := PointList{NewPoint(0, 0), NewPoint(10, 0)}
+	for _, test := range tests {
+		area := totalArea(OffsetPolyline(path, 1, test.join, 2))
+		if !blmath.Equalish(area, test.expected, 0.01) {
+			t.Errorf("Expected %f, got %f\n", test.expected, area)
+		}
+	}
+}
+
+func TestOffsetClosedPolyline(t *testing.T) {
+	type test struct {
+		join     JoinType
+		expected float64
+	}
+	tests := []test{
+		{MiterJoin, 144 - 64},
+		{RoundJoin, 140 + math.Pi - 64},
+		{SquareJoin, 144 - 4*(math.Sqrt2-1)*(math.Sqrt2-1) - 64},
+	}
+	// the last point is the same as the first, so there are no ends, just corners.
+	path := append(square(0, 0, 10), NewPoint(0, 0))
+	reversed := PointList{NewPoint(0, 0), NewPoint(0, 10), NewPoint(10, 10), NewPoint(10, 10), NewPoint(10, 0), NewPoint(0, 0)}
+	for _, test := range tests {
+		for _, p := range []PointList{path, reversed} {
+			result := OffsetPolyline(p, 1, test.join, 2)
+			area := totalArea(result)
+			if !blmath.Equalish(area, test.expected, 0.01) {
+				t.Errorf("Expected %f, got %f\n", test.expected, area)
+			}
+			if len(result) != 2 {
+				t.Errorf("Expected a ring with a hole, got %d polygons\n", len(result))
+			}
+		}
+	}
+}
+
+func TestInsetPolygons(t *testing.T) {
+	insets := InsetPolygons([]PointList{square(0, 0, 10)}, 1, MiterJoin, 2)
+	if len(insets) != 4 {
+		t.Fatalf("Expected %d insets, got %d\n", 4, len(insets))
+	}
+	for i, inset := range insets {
+		size := 8 - float64(i)*2
+		area := totalArea(inset)
+		if !blmath.Equalish(area, size*size, 1e-9) {
+			t.Errorf("Expected %f, got %f\n", size*size, area)
+		}
+	}
+}
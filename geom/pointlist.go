@@ -172,6 +172,17 @@ func (p PointList) BoundingBox() *Rect {
 	return NewRect(minX, minY, maxX-minX, maxY-minY)
 }
 
+// SignedArea returns the area of the polygon made by the points in the list.
+// It is positive if the points go clockwise on screen, with y pointing down, and negative if they go counterclockwise.
+func (p PointList) SignedArea() float64 {
+	area := 0.0
+	for i, p0 := range p {
+		p1 := p[(i+1)%len(p)]
+		area += p0.X*p1.Y - p1.X*p0.Y
+	}
+	return area / 2
+}
+
 // Center returns the local center of the points in the list.
 func (p PointList) Center() *Point {
 	rect := p.BoundingBox()
@@ -0,0 +1,150 @@
+// Package noise creates Perlin and Simplex noise.
+package noise
+
+// The derivative versions of simplex noise return the same value as Simplex2 and Simplex3,
+// along with the partial derivatives of the noise on each axis.
+//
+// Each corner contributes t^4 * (g . d), where d is the offset from the corner, g is the corner's
+// gradient and t = r - d . d. Differentiating that gives -8 * t^3 * (g . d) * d + t^4 * g.
+
+// grad2Vec returns the gradient vector that grad2 uses for a hash.
+func grad2Vec(hash uint8) (float64, float64) {
+	h := hash & 7
+	s1 := q(h&1 != 0, -1, 1)
+	s2 := q(h&2 != 0, -2, 2)
+	if h < 4 {
+		return s1, s2
+	}
+	return s2, s1
+}
+
+// grad3Vec returns the gradient vector that grad3 uses for a hash.
+func grad3Vec(hash uint8) (float64, float64, float64) {
+	h := hash & 15
+	s1 := q(h&1 != 0, -1, 1)
+	s2 := q(h&2 != 0, -1, 1)
+	switch {
+	case h < 4:
+		return s1, s2, 0
+	case h < 8:
+		return s1, 0, s2
+	case h == 12 || h == 14:
+		return s2, s1, 0
+	}
+	return 0, s1, s2
+}
+
+// Simplex2D is 2D simplex noise with its partial derivatives, returned as value, dx, dy.
+func (n *Noise) Simplex2D(x, y float64) (float64, float64, float64) {
+	const F2 = 0.366025403 // F2 = 0.5*(sqrt(3.0)-1.0)
+	const G2 = 0.211324865 // G2 = (3.0-Math.sqrt(3.0))/6.0
+	const scale = 0.022108854818853867
+
+	s := (x + y) * F2
+	i := fastFloor(x + s)
+	j := fastFloor(y + s)
+	t := float64(i+j) * G2
+	x0 := x - (float64(i) - t)
+	y0 := y - (float64(j) - t)
+
+	i1, j1 := 0, 1
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	}
+
+	ii := i & 0xff
+	jj := j & 0xff
+	corners := [3]struct {
+		x, y float64
+		hash uint8
+	}{
+		{x0, y0, n.perm[ii+int(n.perm[jj])]},
+		{x0 - float64(i1) + G2, y0 - float64(j1) + G2, n.perm[ii+i1+int(n.perm[jj+j1])]},
+		{x0 - 1 + 2*G2, y0 - 1 + 2*G2, n.perm[ii+1+int(n.perm[jj+1])]},
+	}
+
+	var value, dx, dy float64
+	for _, c := range corners {
+		t := 0.5 - c.x*c.x - c.y*c.y
+		if t < 0 {
+			continue
+		}
+		gx, gy := grad2Vec(c.hash)
+		dot := gx*c.x + gy*c.y
+		t2 := t * t
+		t4 := t2 * t2
+		value += t4 * dot
+		k := -8 * t2 * t * dot
+		dx += k*c.x + t4*gx
+		dy += k*c.y + t4*gy
+	}
+	return value / scale, dx / scale, dy / scale
+}
+
+// Simplex3D is 3D simplex noise with its partial derivatives, returned as value, dx, dy, dz.
+// Like Simplex3, the noise has tiny jumps at some simplex borders, where the derivatives only describe one side.
+func (n *Noise) Simplex3D(x, y, z float64) (float64, float64, float64, float64) {
+	const F3 = 0.333333333
+	const G3 = 0.166666667
+	const scale = 0.030555466710745972
+
+	s := (x + y + z) * F3
+	i := fastFloor(x + s)
+	j := fastFloor(y + s)
+	k := fastFloor(z + s)
+	t := float64(i+j+k) * G3
+	x0 := x - (float64(i) - t)
+	y0 := y - (float64(j) - t)
+	z0 := z - (float64(k) - t)
+
+	// the same simplex ordering as Simplex3.
+	var i1, j1, k1, i2, j2, k2 int
+	if x0 >= y0 {
+		if y0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0 // X Y Z order
+		} else if x0 >= z0 {
+			i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1 // X Z Y order
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1 // Z X Y order
+		}
+	} else {
+		if y0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1 // Z Y X order
+		} else if x0 < z0 {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1 // Y Z X order
+		} else {
+			i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0 // Y X Z order
+		}
+	}
+
+	ii := i & 0xff
+	jj := j & 0xff
+	kk := k & 0xff
+	corners := [4]struct {
+		x, y, z float64
+		hash    uint8
+	}{
+		{x0, y0, z0, n.perm[ii+int(n.perm[jj+int(n.perm[kk])])]},
+		{x0 - float64(i1) + G3, y0 - float64(j1) + G3, z0 - float64(k1) + G3, n.perm[ii+i1+int(n.perm[jj+j1+int(n.perm[kk+k1])])]},
+		{x0 - float64(i2) + 2*G3, y0 - float64(j2) + 2*G3, z0 - float64(k2) + 2*G3, n.perm[ii+i2+int(n.perm[jj+j2+int(n.perm[kk+k2])])]},
+		{x0 - 1 + 3*G3, y0 - 1 + 3*G3, z0 - 1 + 3*G3, n.perm[ii+1+int(n.perm[jj+1+int(n.perm[kk+1])])]},
+	}
+
+	var value, dx, dy, dz float64
+	for _, c := range corners {
+		t := 0.6 - c.x*c.x - c.y*c.y - c.z*c.z
+		if t < 0 {
+			continue
+		}
+		gx, gy, gz := grad3Vec(c.hash)
+		dot := gx*c.x + gy*c.y + gz*c.z
+		t2 := t * t
+		t4 := t2 * t2
+		value += t4 * dot
+		k := -8 * t2 * t * dot
+		dx += k*c.x + t4*gx
+		dy += k*c.y + t4*gy
+		dz += k*c.z + t4*gz
+	}
+	return value / scale, dx / scale, dy / scale, dz / scale
+}
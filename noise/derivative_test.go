@@ -0,0 +1,73 @@
+// Package noise creates Perlin and Simplex noise.
+package noise
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+// step is the finite difference step used to check derivatives.
+const step = 1e-6
+
+func TestSimplex2D(t *testing.T) {
+	n := New(13)
+	for y := 0.03; y < 6; y += 0.31 {
+		for x := 0.07; x < 6; x += 0.27 {
+			v, dx, dy := n.Simplex2D(x, y)
+			expected := n.Simplex2(x, y)
+			if !blmath.Equalish(v, expected, 1e-12) {
+				t.Errorf("Expected %f, got %f\n", expected, v)
+			}
+			ex := (n.Simplex2(x+step, y) - n.Simplex2(x-step, y)) / (2 * step)
+			ey := (n.Simplex2(x, y+step) - n.Simplex2(x, y-step)) / (2 * step)
+			if !blmath.Equalish(dx, ex, 1e-4) || !blmath.Equalish(dy, ey, 1e-4) {
+				t.Errorf("Expected %f, %f, got %f, %f\n", ex, ey, dx, dy)
+			}
+		}
+	}
+}
+
+func TestSimplex3D(t *testing.T) {
+	n := New(13)
+	// diff returns the central difference of Simplex3 along an axis, and whether the noise is smooth there.
+	// Simplex3 uses a radius of 0.6 around each corner, like the original, which leaves tiny jumps and creases
+	// at some simplex borders. Across a jump the difference grows as the step shrinks,
+	// and across a crease the forward and backward differences disagree.
+	diff := func(x, y, z, ax, ay, az float64) (float64, bool) {
+		f := func(h float64) float64 {
+			return n.Simplex3(x+ax*h, y+ay*h, z+az*h)
+		}
+		central := (f(step) - f(-step)) / (2 * step)
+		fine := (f(step/10) - f(-step/10)) / (step / 5)
+		forward := (f(step) - f(0)) / step
+		backward := (f(0) - f(-step)) / step
+		return central, math.Abs(central-fine) < 1e-5 && math.Abs(forward-backward) < 1e-3
+	}
+	checked := 0
+	for z := 0.11; z < 3; z += 0.53 {
+		for y := 0.03; y < 4; y += 0.31 {
+			for x := 0.07; x < 4; x += 0.27 {
+				v, dx, dy, dz := n.Simplex3D(x, y, z)
+				expected := n.Simplex3(x, y, z)
+				if !blmath.Equalish(v, expected, 1e-12) {
+					t.Errorf("Expected %f, got %f\n", expected, v)
+				}
+				ex, okx := diff(x, y, z, 1, 0, 0)
+				ey, oky := diff(x, y, z, 0, 1, 0)
+				ez, okz := diff(x, y, z, 0, 0, 1)
+				if !okx || !oky || !okz {
+					continue
+				}
+				checked++
+				if !blmath.Equalish(dx, ex, 1e-4) || !blmath.Equalish(dy, ey, 1e-4) || !blmath.Equalish(dz, ez, 1e-4) {
+					t.Errorf("Expected %f, %f, %f, got %f, %f, %f\n", ex, ey, ez, dx, dy, dz)
+				}
+			}
+		}
+	}
+	if checked < 1000 {
+		t.Errorf("Expected most points to be checked, got %d\n", checked)
+	}
+}
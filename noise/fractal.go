@@ -0,0 +1,142 @@
+// Package noise creates Perlin and Simplex noise.
+package noise
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+// Func is a 2d noise function that returns values from about -1.0 to 1.0.
+// Any of the 2d noise functions or methods can be used, such as Simplex2 or n.Perlin2.
+// For 3d or 4d noise, wrap the call in a closure.
+type Func func(x, y float64) float64
+
+// The fractal functions all layer octaves of a base noise function.
+// Each octave is lacunarity times the frequency of the last, and gain times its amplitude.
+// Good starting values are a lacunarity of 2.0 and a gain of 0.5.
+// With fewer than one octave there is no noise, so they all return 0.
+
+// FBM is fractional Brownian motion, the plain sum of octaves of noise.
+// The result is normalized to about -1.0 to 1.0.
+func FBM(f Func, x, y float64, octaves int, lacunarity, gain float64) float64 {
+	if octaves < 1 {
+		return 0
+	}
+	total := 0.0
+	frequency := 1.0
+	amplitude := 1.0
+	maxValue := 0.0
+	for i := 0; i < octaves; i++ {
+		total += f(x*frequency, y*frequency) * amplitude
+		maxValue += amplitude
+		amplitude *= gain
+		frequency *= lacunarity
+	}
+	return total / maxValue
+}
+
+// Ridged is ridged multifractal noise, which folds each octave into sharp ridges.
+// Each octave is weighted by the one before it, so detail builds up along the ridges and the valleys stay smooth.
+// The result is from 0.0 to 1.0.
+func Ridged(f Func, x, y float64, octaves int, lacunarity, gain float64) float64 {
+	if octaves < 1 {
+		return 0
+	}
+	total := 0.0
+	frequency := 1.0
+	amplitude := 1.0
+	maxValue := 0.0
+	weight := 1.0
+	for i := 0; i < octaves; i++ {
+		signal := 1 - math.Abs(f(x*frequency, y*frequency))
+		signal *= signal * weight
+		weight = blmath.Clamp(signal, 0, 1)
+		total += signal * amplitude
+		maxValue += amplitude
+		amplitude *= gain
+		frequency *= lacunarity
+	}
+	return total / maxValue
+}
+
+// Billow is billowy noise, made from the absolute value of each octave, giving rounded puffy shapes.
+// The result is normalized to about -1.0 to 1.0.
+func Billow(f Func, x, y float64, octaves int, lacunarity, gain float64) float64 {
+	if octaves < 1 {
+		return 0
+	}
+	total := 0.0
+	frequency := 1.0
+	amplitude := 1.0
+	maxValue := 0.0
+	for i := 0; i < octaves; i++ {
+		total += (math.Abs(f(x*frequency, y*frequency))*2 - 1) * amplitude
+		maxValue += amplitude
+		amplitude *= gain
+		frequency *= lacunarity
+	}
+	return total / maxValue
+}
+
+// Turbulence is Perlin's turbulence, the sum of the absolute value of each octave.
+// The result is from 0.0 to about 1.0.
+func Turbulence(f Func, x, y float64, octaves int, lacunarity, gain float64) float64 {
+	if octaves < 1 {
+		return 0
+	}
+	total := 0.0
+	frequency := 1.0
+	amplitude := 1.0
+	maxValue := 0.0
+	for i := 0; i < octaves; i++ {
+		total += math.Abs(f(x*frequency, y*frequency)) * amplitude
+		maxValue += amplitude
+		amplitude *= gain
+		frequency *= lacunarity
+	}
+	return total / maxValue
+}
+
+// HybridMulti is Musgrave's hybrid multifractal noise.
+// Each octave is weighted by the ones before it, so low areas stay smooth while high areas get rough.
+// offset is added to each octave before weighting, and 0.7 is a good place to start.
+// The result is not normalized and is mostly positive, growing with offset and octaves.
+func HybridMulti(f Func, x, y float64, octaves int, lacunarity, gain, offset float64) float64 {
+	if octaves < 1 {
+		return 0
+	}
+	total := f(x, y) + offset
+	weight := total
+	frequency := lacunarity
+	amplitude := gain
+	for i := 1; i < octaves; i++ {
+		weight = math.Min(weight, 1)
+		signal := (f(x*frequency, y*frequency) + offset) * amplitude
+		total += weight * signal
+		weight *= signal
+		amplitude *= gain
+		frequency *= lacunarity
+	}
+	return total
+}
+
+// WarpPoint distorts a location using noise. Each iteration offsets the original location by
+// noise sampled at the previously warped location, scaled by strength.
+// One or two iterations are usually enough.
+func WarpPoint(f Func, x, y, strength float64, iterations int) (float64, float64) {
+	wx, wy := x, y
+	for i := 0; i < iterations; i++ {
+		// sample different areas of the noise for each axis so they don't move together.
+		dx := f(wx+5.2, wy+1.3)
+		dy := f(wx+1.7, wy+9.2)
+		wx = x + dx*strength
+		wy = y + dy*strength
+	}
+	return wx, wy
+}
+
+// Warp returns noise sampled at a location that has been distorted by the same noise. See WarpPoint.
+func Warp(f Func, x, y, strength float64, iterations int) float64 {
+	return f(WarpPoint(f, x, y, strength, iterations))
+}
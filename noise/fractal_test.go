@@ -0,0 +1,74 @@
+// Package noise creates Perlin and Simplex noise.
+package noise
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+func TestFractalOctaves(t *testing.T) {
+	type test struct {
+		name    string
+		fractal func(f Func, x, y float64, octaves int, lacunarity, gain float64) float64
+		single  func(v float64) float64
+	}
+	tests := []test{
+		{"FBM", FBM, func(v float64) float64 { return v }},
+		{"Ridged", Ridged, func(v float64) float64 { return (1 - math.Abs(v)) * (1 - math.Abs(v)) }},
+		{"Billow", Billow, func(v float64) float64 { return math.Abs(v)*2 - 1 }},
+		{"Turbulence", Turbulence, func(v float64) float64 { return math.Abs(v) }},
+	}
+	for _, test := range tests {
+		for x := 0.3; x < 4; x += 0.7 {
+			y := x * 1.3
+			result := test.fractal(Simplex2, x, y, 0, 2, 0.5)
+			if result != 0 {
+				t.Errorf("%s with 0 octaves: Expected %f, got %f\n", test.name, 0.0, result)
+			}
+			result = test.fractal(Simplex2, x, y, -1, 2, 0.5)
+			if result != 0 {
+				t.Errorf("%s with -1 octaves: Expected %f, got %f\n", test.name, 0.0, result)
+			}
+			// a single octave is just the base noise, shaped.
+			expected := test.single(Simplex2(x, y))
+			result = test.fractal(Simplex2, x, y, 1, 2, 0.5)
+			if !blmath.Equalish(result, expected, 1e-12) {
+				t.Errorf("%s with 1 octave: Expected %f, got %f\n", test.name, expected, result)
+			}
+		}
+	}
+}
+
+func TestFractalRange(t *testing.T) {
+	for x := 0.1; x < 20; x += 0.23 {
+		y := x * 0.7
+		v := FBM(Simplex2, x, y, 5, 2, 0.5)
+		if v < -1 || v > 1 {
+			t.Errorf("FBM: Expected a value from -1 to 1, got %f\n", v)
+		}
+		v = Ridged(Simplex2, x, y, 5, 2, 0.5)
+		if v < 0 || v > 1 {
+			t.Errorf("Ridged: Expected a value from 0 to 1, got %f\n", v)
+		}
+	}
+}
+
+func TestHybridMultiOctaves(t *testing.T) {
+	for x := 0.3; x < 4; x += 0.7 {
+		y := x * 1.3
+		for _, octaves := range []int{0, -1} {
+			result := HybridMulti(Simplex2, x, y, octaves, 2, 0.5, 0.7)
+			if result != 0 {
+				t.Errorf("Expected %f with %d octaves, got %f\n", 0.0, octaves, result)
+			}
+		}
+		// a single octave is the base noise plus offset.
+		expected := Simplex2(x, y) + 0.7
+		result := HybridMulti(Simplex2, x, y, 1, 2, 0.5, 0.7)
+		if !blmath.Equalish(result, expected, 1e-12) {
+			t.Errorf("Expected %f, got %f\n", expected, result)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Package noise creates Perlin and Simplex noise.
+package noise
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+// Looping and tiling noise works by walking around a circle in extra dimensions.
+// Since a circle ends where it starts, the noise does too.
+
+// LoopNoise1 is 1d noise that loops seamlessly as t goes from 0.0 to 1.0.
+// radius sets how much the noise changes over the course of the loop.
+func (n *Noise) LoopNoise1(x, t, radius float64) float64 {
+	angle := t * blmath.Tau
+	return n.Simplex3(x, math.Cos(angle)*radius, math.Sin(angle)*radius)
+}
+
+// LoopNoise2 is 2d noise that loops seamlessly as t goes from 0.0 to 1.0.
+// radius sets how much the noise changes over the course of the loop.
+func (n *Noise) LoopNoise2(x, y, t, radius float64) float64 {
+	angle := t * blmath.Tau
+	return n.Simplex4(x, y, math.Cos(angle)*radius, math.Sin(angle)*radius)
+}
+
+// TileableNoise1 is 1d noise that repeats every w units.
+func (n *Noise) TileableNoise1(x, w float64) float64 {
+	// a circle with a circumference of w keeps the detail about the same as Simplex1.
+	angle := x / w * blmath.Tau
+	radius := w / blmath.Tau
+	return n.Simplex2(math.Cos(angle)*radius, math.Sin(angle)*radius)
+}
+
+// TileableNoise2 is 2d noise that repeats every w units across and every h units down.
+func (n *Noise) TileableNoise2(x, y, w, h float64) float64 {
+	ax := x / w * blmath.Tau
+	ay := y / h * blmath.Tau
+	rx := w / blmath.Tau
+	ry := h / blmath.Tau
+	return n.Simplex4(math.Cos(ax)*rx, math.Sin(ax)*rx, math.Cos(ay)*ry, math.Sin(ay)*ry)
+}
@@ -0,0 +1,65 @@
+// Package noise creates Perlin and Simplex noise.
+package noise
+
+import (
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+func TestLoopNoise(t *testing.T) {
+	n := New(7)
+	for x := 0.1; x < 5; x += 0.37 {
+		for y := 0.2; y < 5; y += 0.41 {
+			a := n.LoopNoise2(x, y, 0, 1.5)
+			b := n.LoopNoise2(x, y, 1, 1.5)
+			if !blmath.Equalish(a, b, 1e-9) {
+				t.Errorf("Expected %f, got %f\n", a, b)
+			}
+		}
+		a := n.LoopNoise1(x, 0.25, 2)
+		b := n.LoopNoise1(x, 1.25, 2)
+		if !blmath.Equalish(a, b, 1e-9) {
+			t.Errorf("Expected %f, got %f\n", a, b)
+		}
+	}
+}
+
+func TestTileableNoise(t *testing.T) {
+	n := New(7)
+	w, h := 6.0, 4.0
+	for x := 0.1; x < w; x += 0.37 {
+		for y := 0.2; y < h; y += 0.41 {
+			a := n.TileableNoise2(x, y, w, h)
+			for _, offset := range [][2]float64{{w, 0}, {0, h}, {-w, h * 3}} {
+				b := n.TileableNoise2(x+offset[0], y+offset[1], w, h)
+				if !blmath.Equalish(a, b, 1e-9) {
+					t.Errorf("Expected %f, got %f\n", a, b)
+				}
+			}
+		}
+		a := n.TileableNoise1(x, w)
+		b := n.TileableNoise1(x+w*2, w)
+		if !blmath.Equalish(a, b, 1e-9) {
+			t.Errorf("Expected %f, got %f\n", a, b)
+		}
+	}
+}
+
+func TestSimplex4Range(t *testing.T) {
+	n := New(3)
+	low, high := 0.0, 0.0
+	for i := 0; i < 200000; i++ {
+		f := float64(i)
+		v := n.Simplex4(f*0.0731, f*0.0419, f*0.0277, f*0.0163)
+		low = min(low, v)
+		high = max(high, v)
+	}
+	if low < -1 || high > 1 {
+		t.Errorf("Expected values from -1 to 1, got %f to %f\n", low, high)
+	}
+	// the noise should use most of the range.
+	if low > -0.5 || high < 0.5 {
+		t.Errorf("Expected values spread across -1 to 1, got %f to %f\n", low, high)
+	}
+}
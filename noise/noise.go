@@ -0,0 +1,132 @@
+// Package noise creates Perlin and Simplex noise.
+package noise
+
+import "math/rand"
+
+// You can use the noise package in two ways.
+// 1. Call the functions directly from the package. These always give the same noise.
+// example:
+//	 noise.Perlin2(x, y)
+//
+// 2. Create an instance of noise.Noise with a seed and call methods on that object.
+// example:
+//	 n := noise.New(seed)
+//	 n.Perlin2(x, y)
+//
+// Using method 2, different seeds give different noise.
+
+// Noise is a noise generator with its own permutation tables.
+type Noise struct {
+	p    []int
+	perm [512]uint8
+}
+
+// New creates a noise generator whose permutation tables are shuffled using the given seed.
+func New(seed int64) *Noise {
+	rng := rand.New(rand.NewSource(seed))
+	table := rng.Perm(256)
+	n := &Noise{p: make([]int, 512)}
+	for i := 0; i < 512; i++ {
+		n.p[i] = table[i&255]
+		n.perm[i] = uint8(table[i&255])
+	}
+	return n
+}
+
+// defaultNoise is the generator used when functions are called directly via the package.
+// It uses the original fixed tables.
+var defaultNoise = &Noise{p, perm}
+
+// Perlin1 is 1d perlin noise
+func Perlin1(x float64) float64 {
+	return defaultNoise.Perlin1(x)
+}
+
+// Perlin2 is 2d perlin noise
+func Perlin2(x, y float64) float64 {
+	return defaultNoise.Perlin2(x, y)
+}
+
+// Perlin is 3d perlin noise
+func Perlin(x, y, z float64) float64 {
+	return defaultNoise.Perlin(x, y, z)
+}
+
+// PerlinOct creates Perlin noise with given number of octaves.
+// persistence does well at 0.5 to start with.
+func PerlinOct(x, y, z float64, octaves int, persistence float64) float64 {
+	return defaultNoise.PerlinOct(x, y, z, octaves, persistence)
+}
+
+// Simplex1 is 1D simplex noise
+func Simplex1(x float64) float64 {
+	return defaultNoise.Simplex1(x)
+}
+
+// Simplex2 is 2D simplex noise
+func Simplex2(x, y float64) float64 {
+	return defaultNoise.Simplex2(x, y)
+}
+
+// Simplex3 is 3D simplex noise
+func Simplex3(x, y, z float64) float64 {
+	return defaultNoise.Simplex3(x, y, z)
+}
+
+// Simplex4 is 4D simplex noise
+func Simplex4(x, y, z, w float64) float64 {
+	return defaultNoise.Simplex4(x, y, z, w)
+}
+
+// LoopNoise1 is 1d noise that loops seamlessly as t goes from 0.0 to 1.0.
+// radius sets how much the noise changes over the course of the loop.
+func LoopNoise1(x, t, radius float64) float64 {
+	return defaultNoise.LoopNoise1(x, t, radius)
+}
+
+// LoopNoise2 is 2d noise that loops seamlessly as t goes from 0.0 to 1.0.
+// radius sets how much the noise changes over the course of the loop.
+func LoopNoise2(x, y, t, radius float64) float64 {
+	return defaultNoise.LoopNoise2(x, y, t, radius)
+}
+
+// TileableNoise1 is 1d noise that repeats every w units.
+func TileableNoise1(x, w float64) float64 {
+	return defaultNoise.TileableNoise1(x, w)
+}
+
+// TileableNoise2 is 2d noise that repeats every w units across and every h units down.
+func TileableNoise2(x, y, w, h float64) float64 {
+	return defaultNoise.TileableNoise2(x, y, w, h)
+}
+
+// Worley2 is 2d Worley (cellular) noise. There is one feature point randomly placed in each unit square.
+// The pattern repeats every 256 units.
+func Worley2(x, y float64, metric Metric) Cell {
+	return defaultNoise.Worley2(x, y, metric)
+}
+
+// Value1 is 1d value noise
+func Value1(x float64) float64 {
+	return defaultNoise.Value1(x)
+}
+
+// Value2 is 2d value noise
+func Value2(x, y float64) float64 {
+	return defaultNoise.Value2(x, y)
+}
+
+// Value3 is 3d value noise
+func Value3(x, y, z float64) float64 {
+	return defaultNoise.Value3(x, y, z)
+}
+
+// Simplex2D is 2D simplex noise with its partial derivatives, returned as value, dx, dy.
+func Simplex2D(x, y float64) (float64, float64, float64) {
+	return defaultNoise.Simplex2D(x, y)
+}
+
+// Simplex3D is 3D simplex noise with its partial derivatives, returned as value, dx, dy, dz.
+func Simplex3D(x, y, z float64) (float64, float64, float64, float64) {
+	return defaultNoise.Simplex3D(x, y, z)
+}
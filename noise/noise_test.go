@@ -0,0 +1,74 @@
+// Package noise creates Perlin and Simplex noise.
+package noise
+
+import (
+	"testing"
+)
+
+// samples calls f over a small grid and returns the results.
+func samples(f func(x, y float64) float64) []float64 {
+	result := []float64{}
+	for y := 0.13; y < 8; y += 0.71 {
+		for x := 0.37; x < 8; x += 0.53 {
+			result = append(result, f(x, y))
+		}
+	}
+	return result
+}
+
+func TestNewSameSeed(t *testing.T) {
+	a := New(42)
+	b := New(42)
+	funcs := map[string][2]Func{
+		"Perlin2":    {a.Perlin2, b.Perlin2},
+		"Simplex2":   {a.Simplex2, b.Simplex2},
+		"Value2":     {a.Value2, b.Value2},
+		"Simplex3":   {func(x, y float64) float64 { return a.Simplex3(x, y, 0.5) }, func(x, y float64) float64 { return b.Simplex3(x, y, 0.5) }},
+		"Worley2 F1": {func(x, y float64) float64 { return a.Worley2(x, y, Euclidean).F1 }, func(x, y float64) float64 { return b.Worley2(x, y, Euclidean).F1 }},
+	}
+	for name, f := range funcs {
+		sa, sb := samples(f[0]), samples(f[1])
+		for i := range sa {
+			if sa[i] != sb[i] {
+				t.Errorf("%s: Expected %f, got %f\n", name, sa[i], sb[i])
+				break
+			}
+		}
+	}
+}
+
+func TestNewDifferentSeeds(t *testing.T) {
+	a := New(1)
+	b := New(2)
+	funcs := map[string][2]Func{
+		"Perlin2":  {a.Perlin2, b.Perlin2},
+		"Simplex2": {a.Simplex2, b.Simplex2},
+		"Value2":   {a.Value2, b.Value2},
+	}
+	for name, f := range funcs {
+		sa, sb := samples(f[0]), samples(f[1])
+		same := 0
+		for i := range sa {
+			if sa[i] == sb[i] {
+				same++
+			}
+		}
+		// a few samples can match by chance, such as on lattice points where gradient noise is 0.
+		if same > len(sa)/10 {
+			t.Errorf("%s: Expected different seeds to give different noise, %d of %d samples matched\n", name, same, len(sa))
+		}
+	}
+}
+
+func TestDefaultNoise(t *testing.T) {
+	// creating seeded generators doesn't change the tables used by the package funcs.
+	a := samples(Simplex2)
+	New(99)
+	b := samples(Simplex2)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("Expected %f, got %f\n", a[i], b[i])
+			break
+		}
+	}
+}
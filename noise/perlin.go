@@ -6,17 +6,17 @@ import (
 )
 
 // Perlin1 is 1d perlin noise
-func Perlin1(x float64) float64 {
-	return Perlin(x, 0, 0)
+func (n *Noise) Perlin1(x float64) float64 {
+	return n.Perlin(x, 0, 0)
 }
 
 // Perlin2 is 2d perlin noise
-func Perlin2(x, y float64) float64 {
-	return Perlin(x, y, 0)
+func (n *Noise) Perlin2(x, y float64) float64 {
+	return n.Perlin(x, y, 0)
 }
 
 // Perlin is 3d perlin noise
-func Perlin(x, y, z float64) float64 {
+func (n *Noise) Perlin(x, y, z float64) float64 {
 	X := int(math.Floor(x)) & 255
 	Y := int(math.Floor(y)) & 255
 	Z := int(math.Floor(z)) & 255
@@ -26,20 +26,20 @@ func Perlin(x, y, z float64) float64 {
 	u := fade(x)
 	v := fade(y)
 	w := fade(z)
-	A := p[X] + Y
-	AA := p[A] + Z
-	AB := p[A+1] + Z
-	B := p[X+1] + Y
-	BA := p[B] + Z
-	BB := p[B+1] + Z
-	return lerp(w, lerp(v, lerp(u, grad(p[AA], x, y, z),
-		grad(p[BA], x-1, y, z)),
-		lerp(u, grad(p[AB], x, y-1, z),
-			grad(p[BB], x-1, y-1, z))),
-		lerp(v, lerp(u, grad(p[AA+1], x, y, z-1),
-			grad(p[BA+1], x-1, y, z-1)),
-			lerp(u, grad(p[AB+1], x, y-1, z-1),
-				grad(p[BB+1], x-1, y-1, z-1))))
+	A := n.p[X] + Y
+	AA := n.p[A] + Z
+	AB := n.p[A+1] + Z
+	B := n.p[X+1] + Y
+	BA := n.p[B] + Z
+	BB := n.p[B+1] + Z
+	return lerp(w, lerp(v, lerp(u, grad(n.p[AA], x, y, z),
+		grad(n.p[BA], x-1, y, z)),
+		lerp(u, grad(n.p[AB], x, y-1, z),
+			grad(n.p[BB], x-1, y-1, z))),
+		lerp(v, lerp(u, grad(n.p[AA+1], x, y, z-1),
+			grad(n.p[BA+1], x-1, y, z-1)),
+			lerp(u, grad(n.p[AB+1], x, y-1, z-1),
+				grad(n.p[BB+1], x-1, y-1, z-1))))
 }
 func fade(t float64) float64       { return t * t * t * (t*(t*6-15) + 10) }
 func lerp(t, a, b float64) float64 { return a + t*(b-a) }
@@ -97,13 +97,13 @@ var p = append(permutation, permutation...)
 
 // PerlinOct creates Perlin noise with given number of octaves.
 // persistence does well at 0.5 to start with.
-func PerlinOct(x, y, z float64, octaves int, persistence float64) float64 {
+func (n *Noise) PerlinOct(x, y, z float64, octaves int, persistence float64) float64 {
 	total := 0.0
 	frequency := 1.0
 	amplitude := 1.0
 	maxValue := 0.0 // Used for normalizing result to -1.0 - 1.0
 	for i := 0; i < octaves; i++ {
-		total += Perlin(x*frequency, y*frequency, z*frequency) * amplitude
+		total += n.Perlin(x*frequency, y*frequency, z*frequency) * amplitude
 
 		maxValue += amplitude
 
@@ -140,7 +140,7 @@ func grad3(hash uint8, x, y, z float64) float64 {
 }
 
 // Simplex1 is 1D simplex noise
-func Simplex1(x float64) float64 {
+func (n *Noise) Simplex1(x float64) float64 {
 	i0 := fastFloor(x)
 	i1 := i0 + 1
 	x0 := x - float64(i0)
@@ -148,11 +148,11 @@ func Simplex1(x float64) float64 {
 
 	t0 := 1 - x0*x0
 	t0 *= t0
-	n0 := t0 * t0 * grad1(perm[i0&0xff], x0)
+	n0 := t0 * t0 * grad1(n.perm[i0&0xff], x0)
 
 	t1 := 1 - x1*x1
 	t1 *= t1
-	n1 := t1 * t1 * grad1(perm[i1&0xff], x1)
+	n1 := t1 * t1 * grad1(n.perm[i1&0xff], x1)
 	// The maximum value of this noise is 8*(3/4)^4 = 2.53125
 	// A factor of 0.395 would scale to fit exactly within [-1,1].
 	// fmt.Printf("Noise1 x %.4f, i0 %v, i1 %v, x0 %.4f, x1 %.4f, perm0 %d, perm1 %d: %.4f,%.4f\n", x, i0, i1, x0, x1, perm[i0&0xff], perm[i1&0xff], n0, n1)
@@ -161,7 +161,7 @@ func Simplex1(x float64) float64 {
 }
 
 // Simplex2 is 2D simplex noise
-func Simplex2(x, y float64) float64 {
+func (n *Noise) Simplex2(x, y float64) float64 {
 
 	const F2 = 0.366025403 // F2 = 0.5*(sqrt(3.0)-1.0)
 	const G2 = 0.211324865 // G2 = (3.0-Math.sqrt(3.0))/6.0
@@ -211,7 +211,7 @@ func Simplex2(x, y float64) float64 {
 		n0 = 0
 	} else {
 		t0 *= t0
-		n0 = t0 * t0 * grad2(perm[ii+int(perm[jj])], x0, y0)
+		n0 = t0 * t0 * grad2(n.perm[ii+int(n.perm[jj])], x0, y0)
 	}
 
 	t1 := 0.5 - x1*x1 - y1*y1
@@ -219,7 +219,7 @@ func Simplex2(x, y float64) float64 {
 		n1 = 0
 	} else {
 		t1 *= t1
-		n1 = t1 * t1 * grad2(perm[ii+i1+int(perm[jj+j1])], x1, y1)
+		n1 = t1 * t1 * grad2(n.perm[ii+i1+int(n.perm[jj+j1])], x1, y1)
 	}
 
 	t2 := 0.5 - x2*x2 - y2*y2
@@ -227,7 +227,7 @@ func Simplex2(x, y float64) float64 {
 		n2 = 0
 	} else {
 		t2 *= t2
-		n2 = t2 * t2 * grad2(perm[ii+1+int(perm[jj+1])], x2, y2)
+		n2 = t2 * t2 * grad2(n.perm[ii+1+int(n.perm[jj+1])], x2, y2)
 	}
 
 	// Add contributions from each corner to get the final noise value.
@@ -236,7 +236,7 @@ func Simplex2(x, y float64) float64 {
 }
 
 // Simplex3 is 3D simplex noise
-func Simplex3(x, y, z float64) float64 {
+func (n *Noise) Simplex3(x, y, z float64) float64 {
 
 	// Simple skewing factors for the 3D case
 	const F3 = 0.333333333
@@ -341,7 +341,7 @@ func Simplex3(x, y, z float64) float64 {
 		n0 = 0
 	} else {
 		t0 *= t0
-		n0 = t0 * t0 * grad3(perm[ii+int(perm[jj+int(perm[kk])])], x0, y0, z0)
+		n0 = t0 * t0 * grad3(n.perm[ii+int(n.perm[jj+int(n.perm[kk])])], x0, y0, z0)
 	}
 
 	t1 := 0.6 - x1*x1 - y1*y1 - z1*z1
@@ -349,7 +349,7 @@ func Simplex3(x, y, z float64) float64 {
 		n1 = 0
 	} else {
 		t1 *= t1
-		n1 = t1 * t1 * grad3(perm[ii+i1+int(perm[jj+j1+int(perm[kk+k1])])], x1, y1, z1)
+		n1 = t1 * t1 * grad3(n.perm[ii+i1+int(n.perm[jj+j1+int(n.perm[kk+k1])])], x1, y1, z1)
 	}
 
 	t2 := 0.6 - x2*x2 - y2*y2 - z2*z2
@@ -357,7 +357,7 @@ func Simplex3(x, y, z float64) float64 {
 		n2 = 0
 	} else {
 		t2 *= t2
-		n2 = t2 * t2 * grad3(perm[ii+i2+int(perm[jj+j2+int(perm[kk+k2])])], x2, y2, z2)
+		n2 = t2 * t2 * grad3(n.perm[ii+i2+int(n.perm[jj+j2+int(n.perm[kk+k2])])], x2, y2, z2)
 	}
 
 	t3 := 0.6 - x3*x3 - y3*y3 - z3*z3
@@ -365,10 +365,155 @@ func Simplex3(x, y, z float64) float64 {
 		n3 = 0
 	} else {
 		t3 *= t3
-		n3 = t3 * t3 * grad3(perm[ii+1+int(perm[jj+1+int(perm[kk+1])])], x3, y3, z3)
+		n3 = t3 * t3 * grad3(n.perm[ii+1+int(n.perm[jj+1+int(n.perm[kk+1])])], x3, y3, z3)
 	}
 
 	// Add contributions from each corner to get the final noise value.
 	// The result is scaled to stay just inside [-1,1]
 	return (n0 + n1 + n2 + n3) / 0.030555466710745972
 }
+
+func grad4(hash uint8, x, y, z, t float64) float64 {
+	h := hash & 31       // Convert low 5 bits of hash code into 32 simple
+	u := q(h < 24, x, y) // gradient directions, and compute dot product.
+	v := q(h < 16, y, z)
+	w := q(h < 8, z, t)
+	return q(h&1 != 0, -u, u) + q(h&2 != 0, -v, v) + q(h&4 != 0, -w, w)
+}
+
+// Simplex4 is 4D simplex noise
+func (n *Noise) Simplex4(x, y, z, w float64) float64 {
+
+	// The skewing and unskewing factors are hairy again for the 4D case
+	const F4 = 0.309016994 // F4 = (Math.sqrt(5.0)-1.0)/4.0
+	const G4 = 0.138196601 // G4 = (5.0-Math.sqrt(5.0))/20.0
+
+	var n0, n1, n2, n3, n4 float64 // Noise contributions from the five corners
+
+	// Skew the (x,y,z,w) space to determine which cell of 24 simplices we're in
+	s := (x + y + z + w) * F4 // Factor for 4D skewing
+	i := fastFloor(x + s)
+	j := fastFloor(y + s)
+	k := fastFloor(z + s)
+	l := fastFloor(w + s)
+
+	t := float64(i+j+k+l) * G4 // Factor for 4D unskewing
+	x0 := x - (float64(i) - t) // The x,y,z,w distances from the cell origin
+	y0 := y - (float64(j) - t)
+	z0 := z - (float64(k) - t)
+	w0 := w - (float64(l) - t)
+
+	// For the 4D case, the simplex is a 4D shape I won't even try to describe.
+	// The order of the coordinates' magnitudes decides which simplex we are in.
+	// Rank each coordinate by how many of the others it is larger than.
+	var rankx, ranky, rankz, rankw int
+	if x0 > y0 {
+		rankx++
+	} else {
+		ranky++
+	}
+	if x0 > z0 {
+		rankx++
+	} else {
+		rankz++
+	}
+	if x0 > w0 {
+		rankx++
+	} else {
+		rankw++
+	}
+	if y0 > z0 {
+		ranky++
+	} else {
+		rankz++
+	}
+	if y0 > w0 {
+		ranky++
+	} else {
+		rankw++
+	}
+	if z0 > w0 {
+		rankz++
+	} else {
+		rankw++
+	}
+
+	// The largest coordinate steps first, then the next largest, and so on.
+	step := func(rank, min int) int {
+		if rank >= min {
+			return 1
+		}
+		return 0
+	}
+	i1, j1, k1, l1 := step(rankx, 3), step(ranky, 3), step(rankz, 3), step(rankw, 3) // Offsets for second corner
+	i2, j2, k2, l2 := step(rankx, 2), step(ranky, 2), step(rankz, 2), step(rankw, 2) // Offsets for third corner
+	i3, j3, k3, l3 := step(rankx, 1), step(ranky, 1), step(rankz, 1), step(rankw, 1) // Offsets for fourth corner
+
+	// The fifth corner has all coordinate offsets = 1, so no need to look that up.
+	x1 := x0 - float64(i1) + G4 // Offsets for second corner in (x,y,z,w) coords
+	y1 := y0 - float64(j1) + G4
+	z1 := z0 - float64(k1) + G4
+	w1 := w0 - float64(l1) + G4
+	x2 := x0 - float64(i2) + 2*G4 // Offsets for third corner in (x,y,z,w) coords
+	y2 := y0 - float64(j2) + 2*G4
+	z2 := z0 - float64(k2) + 2*G4
+	w2 := w0 - float64(l2) + 2*G4
+	x3 := x0 - float64(i3) + 3*G4 // Offsets for fourth corner in (x,y,z,w) coords
+	y3 := y0 - float64(j3) + 3*G4
+	z3 := z0 - float64(k3) + 3*G4
+	w3 := w0 - float64(l3) + 3*G4
+	x4 := x0 - 1 + 4*G4 // Offsets for last corner in (x,y,z,w) coords
+	y4 := y0 - 1 + 4*G4
+	z4 := z0 - 1 + 4*G4
+	w4 := w0 - 1 + 4*G4
+
+	// Wrap the integer indices at 256, to avoid indexing perm[] out of bounds
+	ii := i & 0xff
+	jj := j & 0xff
+	kk := k & 0xff
+	ll := l & 0xff
+
+	// Calculate the contribution from the five corners
+	t0 := 0.6 - x0*x0 - y0*y0 - z0*z0 - w0*w0
+	if t0 < 0 {
+		n0 = 0
+	} else {
+		t0 *= t0
+		n0 = t0 * t0 * grad4(n.perm[ii+int(n.perm[jj+int(n.perm[kk+int(n.perm[ll])])])], x0, y0, z0, w0)
+	}
+
+	t1 := 0.6 - x1*x1 - y1*y1 - z1*z1 - w1*w1
+	if t1 < 0 {
+		n1 = 0
+	} else {
+		t1 *= t1
+		n1 = t1 * t1 * grad4(n.perm[ii+i1+int(n.perm[jj+j1+int(n.perm[kk+k1+int(n.perm[ll+l1])])])], x1, y1, z1, w1)
+	}
+
+	t2 := 0.6 - x2*x2 - y2*y2 - z2*z2 - w2*w2
+	if t2 < 0 {
+		n2 = 0
+	} else {
+		t2 *= t2
+		n2 = t2 * t2 * grad4(n.perm[ii+i2+int(n.perm[jj+j2+int(n.perm[kk+k2+int(n.perm[ll+l2])])])], x2, y2, z2, w2)
+	}
+
+	t3 := 0.6 - x3*x3 - y3*y3 - z3*z3 - w3*w3
+	if t3 < 0 {
+		n3 = 0
+	} else {
+		t3 *= t3
+		n3 = t3 * t3 * grad4(n.perm[ii+i3+int(n.perm[jj+j3+int(n.perm[kk+k3+int(n.perm[ll+l3])])])], x3, y3, z3, w3)
+	}
+
+	t4 := 0.6 - x4*x4 - y4*y4 - z4*z4 - w4*w4
+	if t4 < 0 {
+		n4 = 0
+	} else {
+		t4 *= t4
+		n4 = t4 * t4 * grad4(n.perm[ii+1+int(n.perm[jj+1+int(n.perm[kk+1+int(n.perm[ll+1])])])], x4, y4, z4, w4)
+	}
+
+	// Sum up and scale the result to cover the range [-1,1]
+	return 27 * (n0 + n1 + n2 + n3 + n4)
+}
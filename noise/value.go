@@ -0,0 +1,61 @@
+// Package noise creates Perlin and Simplex noise.
+package noise
+
+import (
+	"math"
+)
+
+// Value noise picks a random value at each integer location and smoothly interpolates between them.
+// It is blockier than gradient noise, but cheap. All results are from -1.0 to 1.0, repeating every 256 units.
+
+// latticeValue converts a permutation table entry to a value from -1.0 to 1.0.
+func latticeValue(hash uint8) float64 {
+	return float64(hash)/127.5 - 1
+}
+
+// Value1 is 1d value noise
+func (n *Noise) Value1(x float64) float64 {
+	X := int(math.Floor(x))
+	u := fade(x - float64(X))
+	i := X & 0xff
+	return lerp(u, latticeValue(n.perm[i]), latticeValue(n.perm[i+1]))
+}
+
+// Value2 is 2d value noise
+func (n *Noise) Value2(x, y float64) float64 {
+	X := int(math.Floor(x))
+	Y := int(math.Floor(y))
+	u := fade(x - float64(X))
+	v := fade(y - float64(Y))
+	i := X & 0xff
+	j := Y & 0xff
+	hash := func(i, j int) float64 {
+		return latticeValue(n.perm[int(n.perm[i])+j])
+	}
+	return lerp(v,
+		lerp(u, hash(i, j), hash(i+1, j)),
+		lerp(u, hash(i, j+1), hash(i+1, j+1)))
+}
+
+// Value3 is 3d value noise
+func (n *Noise) Value3(x, y, z float64) float64 {
+	X := int(math.Floor(x))
+	Y := int(math.Floor(y))
+	Z := int(math.Floor(z))
+	u := fade(x - float64(X))
+	v := fade(y - float64(Y))
+	w := fade(z - float64(Z))
+	i := X & 0xff
+	j := Y & 0xff
+	k := Z & 0xff
+	hash := func(i, j, k int) float64 {
+		return latticeValue(n.perm[int(n.perm[int(n.perm[i])+j])+k])
+	}
+	return lerp(w,
+		lerp(v,
+			lerp(u, hash(i, j, k), hash(i+1, j, k)),
+			lerp(u, hash(i, j+1, k), hash(i+1, j+1, k))),
+		lerp(v,
+			lerp(u, hash(i, j, k+1), hash(i+1, j, k+1)),
+			lerp(u, hash(i, j+1, k+1), hash(i+1, j+1, k+1))))
+}
@@ -0,0 +1,84 @@
+// Package noise creates Perlin and Simplex noise.
+package noise
+
+import (
+	"math"
+)
+
+// Metric is the way distances to feature points are measured in Worley noise.
+type Metric int
+
+const (
+	// Euclidean is straight line distance, giving round cells.
+	Euclidean Metric = iota
+	// Manhattan is the sum of the distances on each axis, giving diamond shaped cells.
+	Manhattan
+	// Chebyshev is the largest of the distances on each axis, giving square cells.
+	Chebyshev
+)
+
+// distance measures a distance using the metric.
+func (m Metric) distance(dx, dy float64) float64 {
+	switch m {
+	case Manhattan:
+		return math.Abs(dx) + math.Abs(dy)
+	case Chebyshev:
+		return math.Max(math.Abs(dx), math.Abs(dy))
+	}
+	return math.Hypot(dx, dy)
+}
+
+// Cell is the result of a Worley noise lookup.
+type Cell struct {
+	// F1 is the distance to the closest feature point.
+	F1 float64
+	// F2 is the distance to the second closest feature point.
+	F2 float64
+	// ID identifies the closest feature point, useful for giving each cell its own color.
+	// It is unique within the 256 by 256 area that the noise repeats over.
+	ID int
+	// X and Y are the location of the closest feature point.
+	X, Y float64
+}
+
+// Edge returns F2 - F1, which is 0 on the borders between cells.
+func (c Cell) Edge() float64 {
+	return c.F2 - c.F1
+}
+
+// Worley2 is 2d Worley (cellular) noise. There is one feature point randomly placed in each unit square.
+// The pattern repeats every 256 units.
+func (n *Noise) Worley2(x, y float64, metric Metric) Cell {
+	i := int(math.Floor(x))
+	j := int(math.Floor(y))
+	cell := Cell{F1: math.Inf(1), F2: math.Inf(1)}
+	// a feature point two cells away can still be closer than the one in this cell.
+	for cj := j - 2; cj <= j+2; cj++ {
+		for ci := i - 2; ci <= i+2; ci++ {
+			h := int(n.perm[int(n.perm[ci&0xff])+cj&0xff])
+			px := float64(ci) + float64(int(n.perm[h])<<8|int(n.perm[h+1]))/65536
+			py := float64(cj) + float64(int(n.perm[h+2])<<8|int(n.perm[h+3]))/65536
+			d := metric.distance(px-x, py-y)
+			if d < cell.F1 {
+				cell.F2 = cell.F1
+				cell.F1 = d
+				cell.ID = n.cellID(ci, cj)
+				cell.X = px
+				cell.Y = py
+			} else if d < cell.F2 {
+				cell.F2 = d
+			}
+		}
+	}
+	return cell
+}
+
+// cellID scrambles the coords of a cell into an id, so neighboring cells don't get similar ids.
+// Each step can be undone, so every cell in the 256 by 256 area gets a different id.
+func (n *Noise) cellID(i, j int) int {
+	a := i & 0xff
+	b := j & 0xff
+	b ^= int(n.perm[a])
+	a ^= int(n.perm[b])
+	return a<<8 | b
+}
@@ -0,0 +1,62 @@
+// Package noise creates Perlin and Simplex noise.
+package noise
+
+import (
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+func TestWorley2(t *testing.T) {
+	n := New(11)
+	for _, metric := range []Metric{Euclidean, Manhattan, Chebyshev} {
+		for y := 0.05; y < 12; y += 0.37 {
+			for x := 0.05; x < 12; x += 0.29 {
+				cell := n.Worley2(x, y, metric)
+				if cell.F1 > cell.F2 {
+					t.Errorf("Expected F1 <= F2, got %f, %f\n", cell.F1, cell.F2)
+				}
+				if cell.Edge() < 0 {
+					t.Errorf("Expected Edge >= 0, got %f\n", cell.Edge())
+				}
+				d := metric.distance(cell.X-x, cell.Y-y)
+				if !blmath.Equalish(cell.F1, d, 1e-12) {
+					t.Errorf("Expected F1 to be the distance to X, Y, %f, got %f\n", d, cell.F1)
+				}
+				// the pattern repeats every 256 units.
+				other := n.Worley2(x+256, y-512, metric)
+				if !blmath.Equalish(cell.F1, other.F1, 1e-9) || cell.ID != other.ID {
+					t.Errorf("Expected %f, %d, got %f, %d\n", cell.F1, cell.ID, other.F1, other.ID)
+				}
+			}
+		}
+	}
+}
+
+func TestCellID(t *testing.T) {
+	for _, n := range []*Noise{defaultNoise, New(5)} {
+		seen := make(map[int]bool)
+		for j := 0; j < 256; j++ {
+			for i := 0; i < 256; i++ {
+				id := n.cellID(i, j)
+				if id < 0 || id > 0xffff {
+					t.Errorf("Expected an id from 0 to 65535, got %d\n", id)
+				}
+				if seen[id] {
+					t.Errorf("Expected a unique id for %d, %d, got %d again\n", i, j, id)
+				}
+				seen[id] = true
+				// undo each step to get back to the cell.
+				a, b := id>>8, id&0xff
+				a ^= int(n.perm[b])
+				b ^= int(n.perm[a])
+				if a != i || b != j {
+					t.Errorf("Expected %d, %d, got %d, %d\n", i, j, a, b)
+				}
+				if n.cellID(i+256, j-256) != id {
+					t.Errorf("Expected %d, got %d\n", id, n.cellID(i+256, j-256))
+				}
+			}
+		}
+	}
+}
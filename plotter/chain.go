@@ -0,0 +1,126 @@
+// Package plotter prepares paths for pen plotters.
+package plotter
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// Chain joins segments that share end points into paths, so each path can be drawn without lifting the pen.
+// A path that comes back to where it started has the same first and last point.
+// Points closer than tolerance count as the same point. Tolerances below 1e-9 are raised to 1e-9.
+func Chain(segments geom.SegmentList, tolerance float64) []geom.PointList {
+	if !(tolerance > 1e-9) {
+		tolerance = 1e-9
+	}
+	nodes := newNodeFinder(tolerance)
+	// ends holds the node at each end of each segment, edges the segments that touch each node.
+	ends := make([][2]int, len(segments))
+	edges := [][]int{}
+	for i, s := range segments {
+		for j, p := range []*geom.Point{s.PointA, s.PointB} {
+			node := nodes.find(p)
+			if node == len(edges) {
+				edges = append(edges, []int{})
+			}
+			ends[i][j] = node
+			edges[node] = append(edges[node], i)
+		}
+	}
+
+	used := make([]bool, len(segments))
+	// next returns an unused segment touching a node.
+	next := func(node int) (int, bool) {
+		for len(edges[node]) > 0 {
+			seg := edges[node][0]
+			edges[node] = edges[node][1:]
+			if !used[seg] {
+				return seg, true
+			}
+		}
+		return 0, false
+	}
+	// walk follows unused segments from a node until it runs out.
+	walk := func(node int) geom.PointList {
+		var path geom.PointList
+		for {
+			seg, ok := next(node)
+			if !ok {
+				return path
+			}
+			used[seg] = true
+			s := segments[seg]
+			a, b := s.PointA, s.PointB
+			if ends[seg][0] != node {
+				a, b = b, a
+			}
+			if path == nil {
+				path = geom.PointList{geom.NewPoint(a.X, a.Y)}
+			}
+			path.AddXY(b.X, b.Y)
+			node = ends[seg][0] + ends[seg][1] - node
+		}
+	}
+
+	// start at the odd nodes, which have to be path ends, so that the paths through them are as long as possible.
+	degree := make([]int, len(edges))
+	for _, e := range ends {
+		degree[e[0]]++
+		degree[e[1]]++
+	}
+	paths := []geom.PointList{}
+	for _, odd := range []bool{true, false} {
+		for node := range edges {
+			if (degree[node]%2 == 1) != odd {
+				continue
+			}
+			for {
+				path := walk(node)
+				if path == nil {
+					break
+				}
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+//////////////////////////////
+// Node finder
+//////////////////////////////
+
+// nodeFinder merges points that are within tolerance of each other into numbered nodes.
+type nodeFinder struct {
+	size  float64
+	cells map[[2]int][]int
+	nodes geom.PointList
+}
+
+// newNodeFinder creates a new nodeFinder.
+func newNodeFinder(tolerance float64) *nodeFinder {
+	return &nodeFinder{
+		size:  tolerance,
+		cells: map[[2]int][]int{},
+	}
+}
+
+// find returns the node for a point, adding a new one if no node is within tolerance.
+func (n *nodeFinder) find(p *geom.Point) int {
+	cx := int(math.Floor(p.X / n.size))
+	cy := int(math.Floor(p.Y / n.size))
+	for x := cx - 1; x <= cx+1; x++ {
+		for y := cy - 1; y <= cy+1; y++ {
+			for _, node := range n.cells[[2]int{x, y}] {
+				if n.nodes[node].Distance(p) <= n.size {
+					return node
+				}
+			}
+		}
+	}
+	node := len(n.nodes)
+	n.nodes.Add(p)
+	n.cells[[2]int{cx, cy}] = append(n.cells[[2]int{cx, cy}], node)
+	return node
+}
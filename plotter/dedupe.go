@@ -0,0 +1,139 @@
+// Package plotter prepares paths for pen plotters.
+package plotter
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// angleTolerance is how close, in radians, the directions of two segments must be for them to be collinear.
+const angleTolerance = 1e-6
+
+// line holds a segment's position along the infinite line that it lies on.
+type line struct {
+	index      int
+	angle      float64
+	offset     float64
+	start, end float64
+}
+
+// Dedupe returns a list of segments with duplicates removed. Segments that Segment.Equals would match, in
+// either direction, are dropped, and collinear segments that overlap are merged into one segment.
+// Segments that touch end to end are left alone, as are zero length segments, which are removed.
+// Points closer than tolerance count as the same point.
+func Dedupe(segments geom.SegmentList, tolerance float64) geom.SegmentList {
+	tolerance = math.Max(tolerance, 1e-9)
+	lines := []line{}
+	for i, s := range segments {
+		if s.Length() < tolerance {
+			continue
+		}
+		angle := math.Atan2(s.PointB.Y-s.PointA.Y, s.PointB.X-s.PointA.X)
+		if angle < 0 {
+			angle += math.Pi
+		}
+		if angle > math.Pi-angleTolerance {
+			angle -= math.Pi
+		}
+		cos, sin := math.Cos(angle), math.Sin(angle)
+		a := s.PointA.X*cos + s.PointA.Y*sin
+		b := s.PointB.X*cos + s.PointB.Y*sin
+		lines = append(lines, line{
+			index:  i,
+			angle:  angle,
+			offset: s.PointA.Y*cos - s.PointA.X*sin,
+			start:  math.Min(a, b),
+			end:    math.Max(a, b),
+		})
+	}
+
+	// group by direction, then by offset, then merge along the line.
+	type merged struct {
+		first int
+		seg   *geom.Segment
+	}
+	result := []merged{}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].angle < lines[j].angle })
+	for _, byAngle := range groups(lines, angleTolerance, func(l line) float64 { return l.angle }) {
+		sort.Slice(byAngle, func(i, j int) bool { return byAngle[i].offset < byAngle[j].offset })
+		for _, byOffset := range groups(byAngle, tolerance, func(l line) float64 { return l.offset }) {
+			sort.Slice(byOffset, func(i, j int) bool { return byOffset[i].start < byOffset[j].start })
+			run := []line{byOffset[0]}
+			end := byOffset[0].end
+			flush := func() {
+				result = append(result, merged{first: firstIndex(run), seg: mergeRun(segments, run, end)})
+			}
+			for _, l := range byOffset[1:] {
+				if l.start < end-tolerance {
+					run = append(run, l)
+					end = math.Max(end, l.end)
+					continue
+				}
+				flush()
+				run = []line{l}
+				end = l.end
+			}
+			flush()
+		}
+	}
+
+	// keep the original order as far as possible.
+	sort.Slice(result, func(i, j int) bool { return result[i].first < result[j].first })
+	unique := geom.NewSegmentList()
+	for _, m := range result {
+		unique.Add(m.seg)
+	}
+	return unique
+}
+
+// groups splits a sorted list of lines into runs where each value is within tolerance of the one before it.
+func groups(lines []line, tolerance float64, value func(line) float64) [][]line {
+	result := [][]line{}
+	start := 0
+	for i := 1; i <= len(lines); i++ {
+		if i == len(lines) || value(lines[i])-value(lines[i-1]) > tolerance {
+			result = append(result, lines[start:i])
+			start = i
+		}
+	}
+	return result
+}
+
+// firstIndex returns the lowest segment index in a run.
+func firstIndex(run []line) int {
+	first := run[0].index
+	for _, l := range run {
+		first = min(first, l.index)
+	}
+	return first
+}
+
+// mergeRun returns a single segment covering a run of overlapping collinear segments.
+// A run of one segment, or one where a single segment covers the rest, returns that segment.
+func mergeRun(segments geom.SegmentList, run []line, end float64) *geom.Segment {
+	start := run[0].start
+	for _, l := range run {
+		if l.start <= start && l.end >= end {
+			return segments[l.index]
+		}
+	}
+
+	// the ends of the run come from the segments that reach furthest each way.
+	var a, b *geom.Point
+	for _, l := range run {
+		s := segments[l.index]
+		cos, sin := math.Cos(l.angle), math.Sin(l.angle)
+		for _, p := range []*geom.Point{s.PointA, s.PointB} {
+			t := p.X*cos + p.Y*sin
+			if t == start && a == nil {
+				a = p
+			}
+			if t == end && b == nil {
+				b = p
+			}
+		}
+	}
+	return geom.NewSegment(a.X, a.Y, b.X, b.Y)
+}
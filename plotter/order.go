@@ -0,0 +1,240 @@
+// Package plotter prepares paths for pen plotters.
+package plotter
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+const (
+	// twoOptWindow is how many paths ahead 2-opt looks for a better order.
+	twoOptWindow = 100
+	// twoOptPasses is the most passes 2-opt makes over the paths.
+	twoOptPasses = 10
+)
+
+// step is a path in the drawing order, and whether it is drawn backwards.
+type step struct {
+	path     int
+	reversed bool
+}
+
+// Order returns the paths in an order that cuts down pen up travel, starting from 0, 0.
+// It makes a greedy nearest neighbor pass, drawing paths backwards where that is shorter,
+// then improves the order with 2-opt. Paths that come back to their start are not rotated.
+func Order(paths []geom.PointList) []geom.PointList {
+	drawable := []geom.PointList{}
+	for _, path := range paths {
+		if len(path) > 0 {
+			drawable = append(drawable, path)
+		}
+	}
+	steps := twoOpt(drawable, nearestNeighbor(drawable))
+	result := make([]geom.PointList, len(steps))
+	for i, s := range steps {
+		path := drawable[s.path]
+		if s.reversed {
+			path = reversed(path)
+		}
+		result[i] = path
+	}
+	return result
+}
+
+// nearestNeighbor orders paths by always going to the closest end of a path not drawn yet.
+func nearestNeighbor(paths []geom.PointList) []step {
+	grid := newEndGrid(paths)
+	steps := make([]step, 0, len(paths))
+	pen := geom.NewPoint(0, 0)
+	for range paths {
+		s := grid.nearest(pen)
+		grid.remove(s.path)
+		steps = append(steps, s)
+		pen = end(paths, s)
+	}
+	return steps
+}
+
+// twoOpt improves an order by reversing runs of steps where that cuts down travel.
+// Reversing a run also reverses each path in it, so a run of one just draws a path backwards.
+func twoOpt(paths []geom.PointList, steps []step) []step {
+	origin := geom.NewPoint(0, 0)
+	for pass := 0; pass < twoOptPasses; pass++ {
+		improved := false
+		for i := range steps {
+			before := origin
+			if i > 0 {
+				before = end(paths, steps[i-1])
+			}
+			for j := i; j < len(steps) && j <= i+twoOptWindow; j++ {
+				current := before.Distance(start(paths, steps[i]))
+				swapped := before.Distance(end(paths, steps[j]))
+				if j+1 < len(steps) {
+					after := start(paths, steps[j+1])
+					current += end(paths, steps[j]).Distance(after)
+					swapped += start(paths, steps[i]).Distance(after)
+				}
+				if swapped < current-1e-9 {
+					reverseSteps(steps[i : j+1])
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return steps
+}
+
+// reverseSteps reverses a run of steps in place, along with the direction of each step.
+func reverseSteps(steps []step) {
+	for i, j := 0, len(steps)-1; i <= j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+		steps[i].reversed = !steps[i].reversed
+		if i != j {
+			steps[j].reversed = !steps[j].reversed
+		}
+	}
+}
+
+// start returns the point where a step puts the pen down.
+func start(paths []geom.PointList, s step) *geom.Point {
+	if s.reversed {
+		return paths[s.path].Last()
+	}
+	return paths[s.path].First()
+}
+
+// end returns the point where a step lifts the pen.
+func end(paths []geom.PointList, s step) *geom.Point {
+	if s.reversed {
+		return paths[s.path].First()
+	}
+	return paths[s.path].Last()
+}
+
+// reversed returns a copy of a path in reverse order.
+func reversed(path geom.PointList) geom.PointList {
+	result := make(geom.PointList, len(path))
+	for i, p := range path {
+		result[len(path)-1-i] = p
+	}
+	return result
+}
+
+//////////////////////////////
+// End grid
+//////////////////////////////
+
+// endGrid finds the nearest path end to a point, by putting the ends of paths into grid cells.
+type endGrid struct {
+	paths    []geom.PointList
+	size     float64
+	min, max [2]int
+	cells    map[[2]int][]int
+	removed  []bool
+}
+
+// newEndGrid creates a new endGrid holding the ends of each path.
+func newEndGrid(paths []geom.PointList) *endGrid {
+	ends := geom.NewPointList()
+	for _, path := range paths {
+		ends.Add(path.First())
+		ends.Add(path.Last())
+	}
+	g := &endGrid{
+		paths:   paths,
+		size:    1,
+		cells:   map[[2]int][]int{},
+		removed: make([]bool, len(paths)),
+	}
+	if len(ends) == 0 {
+		return g
+	}
+	// aim for a couple of ends per cell.
+	box := ends.BoundingBox()
+	g.size = math.Max(math.Sqrt(box.W*box.H/float64(len(paths))), math.Max(box.W, box.H)/float64(len(paths)))
+	if g.size == 0 {
+		g.size = 1
+	}
+	g.min = g.cell(geom.NewPoint(box.X, box.Y))
+	g.max = g.cell(geom.NewPoint(box.X+box.W, box.Y+box.H))
+	for i, path := range paths {
+		a := g.cell(path.First())
+		b := g.cell(path.Last())
+		g.cells[a] = append(g.cells[a], i)
+		if b != a {
+			g.cells[b] = append(g.cells[b], i)
+		}
+	}
+	return g
+}
+
+// cell returns the cell that a point falls in.
+func (g *endGrid) cell(p *geom.Point) [2]int {
+	return [2]int{int(math.Floor(p.X / g.size)), int(math.Floor(p.Y / g.size))}
+}
+
+// remove takes a path out of the grid.
+func (g *endGrid) remove(path int) {
+	g.removed[path] = true
+}
+
+// nearest returns the step that starts closest to a point, searching rings of cells around it.
+// Points outside the grid, like the pen's starting point, check every path.
+func (g *endGrid) nearest(p *geom.Point) step {
+	c := g.cell(p)
+	best := step{path: -1}
+	bestDist := math.Inf(1)
+	check := func(path int) {
+		for _, s := range []step{{path, false}, {path, true}} {
+			d := p.Distance(start(g.paths, s))
+			if d < bestDist {
+				best, bestDist = s, d
+			}
+		}
+	}
+	// visit checks the paths in a cell, dropping any that have been removed.
+	visit := func(key [2]int) {
+		cell := g.cells[key]
+		kept := cell[:0]
+		for _, path := range cell {
+			if !g.removed[path] {
+				kept = append(kept, path)
+				check(path)
+			}
+		}
+		if len(kept) < len(cell) {
+			g.cells[key] = kept
+		}
+	}
+
+	if c[0] < g.min[0] || c[0] > g.max[0] || c[1] < g.min[1] || c[1] > g.max[1] {
+		for path := range g.paths {
+			if !g.removed[path] {
+				check(path)
+			}
+		}
+		return best
+	}
+	visit(c)
+	// rings past this one are outside the grid.
+	last := max(c[0]-g.min[0], g.max[0]-c[0], c[1]-g.min[1], g.max[1]-c[1])
+	for r := 1; r <= last; r++ {
+		// anything in this ring is at least r-1 cells away.
+		if bestDist <= float64(r-1)*g.size {
+			break
+		}
+		for i := -r; i <= r; i++ {
+			visit([2]int{c[0] + i, c[1] - r})
+			visit([2]int{c[0] + i, c[1] + r})
+			if i != -r && i != r {
+				visit([2]int{c[0] - r, c[1] + i})
+				visit([2]int{c[0] + r, c[1] + i})
+			}
+		}
+	}
+	return best
+}
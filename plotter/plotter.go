@@ -0,0 +1,59 @@
+// Package plotter prepares paths for pen plotters.
+package plotter
+
+import (
+	"github.com/bit101/bitlib/geom"
+)
+
+// Stats reports the effect of an Optimize.
+type Stats struct {
+	// Segments is the number of segments given.
+	Segments int
+	// Removed is the number of segments dropped as duplicates, or merged with overlapping segments.
+	Removed int
+	// Paths is the number of paths after chaining.
+	Paths int
+	// TravelBefore is the pen up distance drawing the segments one by one in their original order.
+	TravelBefore float64
+	// TravelAfter is the pen up distance drawing the optimized paths.
+	TravelAfter float64
+}
+
+// Optimize turns a list of segments into paths that are quicker to plot. Duplicate and overlapping segments
+// are dropped, touching segments are chained into paths, and the paths are reordered and reversed to cut down
+// pen up travel, starting from 0, 0. Points closer than tolerance count as the same point.
+func Optimize(segments geom.SegmentList, tolerance float64) ([]geom.PointList, Stats) {
+	stats := Stats{
+		Segments:     len(segments),
+		TravelBefore: Travel(segmentPaths(segments)),
+	}
+	unique := Dedupe(segments, tolerance)
+	paths := Order(Chain(unique, tolerance))
+	stats.Removed = len(segments) - len(unique)
+	stats.Paths = len(paths)
+	stats.TravelAfter = Travel(paths)
+	return paths, stats
+}
+
+// Travel returns the pen up distance for drawing a list of paths in order, starting from 0, 0.
+func Travel(paths []geom.PointList) float64 {
+	travel := 0.0
+	pen := geom.NewPoint(0, 0)
+	for _, path := range paths {
+		if len(path) == 0 {
+			continue
+		}
+		travel += pen.Distance(path.First())
+		pen = path.Last()
+	}
+	return travel
+}
+
+// segmentPaths turns each segment into a path of its own.
+func segmentPaths(segments geom.SegmentList) []geom.PointList {
+	paths := make([]geom.PointList, len(segments))
+	for i, s := range segments {
+		paths[i] = geom.PointList{s.PointA, s.PointB}
+	}
+	return paths
+}
@@ -0,0 +1,130 @@
+// Package plotter prepares paths for pen plotters.
+package plotter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+func TestDedupe(t *testing.T) {
+	type test struct {
+		segments geom.SegmentList
+		expected geom.SegmentList
+	}
+	tests := []test{
+		// duplicate, either direction
+		{
+			geom.SegmentList{geom.NewSegment(0, 0, 10, 10), geom.NewSegment(10, 10, 0, 0), geom.NewSegment(0, 0, 10, 10)},
+			geom.SegmentList{geom.NewSegment(0, 0, 10, 10)},
+		},
+		// overlapping
+		{
+			geom.SegmentList{geom.NewSegment(0, 0, 10, 0), geom.NewSegment(15, 0, 5, 0)},
+			geom.SegmentList{geom.NewSegment(0, 0, 15, 0)},
+		},
+		// contained
+		{
+			geom.SegmentList{geom.NewSegment(0, 5, 0, 6), geom.NewSegment(0, 0, 0, 10)},
+			geom.SegmentList{geom.NewSegment(0, 0, 0, 10)},
+		},
+		// touching, parallel and zero length
+		{
+			geom.SegmentList{geom.NewSegment(0, 0, 10, 0), geom.NewSegment(10, 0, 20, 0), geom.NewSegment(0, 1, 10, 1), geom.NewSegment(5, 5, 5, 5)},
+			geom.SegmentList{geom.NewSegment(0, 0, 10, 0), geom.NewSegment(10, 0, 20, 0), geom.NewSegment(0, 1, 10, 1)},
+		},
+	}
+	for _, test := range tests {
+		result := Dedupe(test.segments, 0.001)
+		if len(result) != len(test.expected) {
+			t.Errorf("Expected %d segments, got %d\n", len(test.expected), len(result))
+			continue
+		}
+		for i, s := range result {
+			if !s.Equals(test.expected[i]) {
+				t.Errorf("Expected %v, got %v\n", test.expected[i], s)
+			}
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	segments := geom.NewSegmentList()
+	// a closed square, drawn in a jumbled order.
+	segments.AddXY(0, 0, 10, 0)
+	segments.AddXY(10, 10, 0, 10)
+	segments.AddXY(10, 0, 10, 10)
+	segments.AddXY(0, 0, 0, 10)
+	// an open line with a tail.
+	segments.AddXY(20, 0, 30, 0)
+	segments.AddXY(40, 0, 30, 0.0001)
+
+	paths := Chain(segments, 0.001)
+	if len(paths) != 2 {
+		t.Fatalf("Expected %d paths, got %d\n", 2, len(paths))
+	}
+	lengths := map[int]bool{}
+	for _, path := range paths {
+		lengths[len(path)] = true
+		if len(path) == 5 && !path.First().Equals(path.Last()) {
+			t.Errorf("Expected closed path, got %v to %v\n", path.First(), path.Last())
+		}
+	}
+	if !lengths[5] || !lengths[3] {
+		t.Errorf("Expected paths of %d and %d points\n", 5, 3)
+	}
+}
+
+func TestChainBadTolerance(t *testing.T) {
+	segments := geom.NewSegmentList()
+	segments.AddXY(0, 0, 10, 0)
+	segments.AddXY(10, 0, 10, 10)
+	for _, tolerance := range []float64{0, -1, math.NaN()} {
+		paths := Chain(segments, tolerance)
+		if len(paths) != 1 || len(paths[0]) != 3 {
+			t.Errorf("Expected one path of 3 points for %f, got %v\n", tolerance, paths)
+		}
+	}
+}
+
+func TestOrder(t *testing.T) {
+	paths := []geom.PointList{
+		{geom.NewPoint(30, 0), geom.NewPoint(40, 0)},
+		{geom.NewPoint(20, 0), geom.NewPoint(10, 0)},
+		{geom.NewPoint(0, 1), geom.NewPoint(0, 0)},
+	}
+	result := Order(paths)
+	expected := []float64{0, 10, 30}
+	for i, path := range result {
+		if path.First().X != expected[i] {
+			t.Errorf("Expected %f, got %f\n", expected[i], path.First().X)
+		}
+	}
+	travel := Travel(result)
+	if math.Abs(travel-(math.Sqrt(101)+10)) > 1e-9 {
+		t.Errorf("Expected %f, got %f\n", math.Sqrt(101)+10, travel)
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	segments := geom.NewSegmentList()
+	for i := 9; i >= 0; i-- {
+		x := float64(i * 10)
+		segments.AddXY(x+10, 0, x, 0)
+		segments.AddXY(x, 0, x+10, 0)
+	}
+	paths, stats := Optimize(segments, 0.001)
+	if len(paths) != 1 || len(paths[0]) != 11 {
+		t.Fatalf("Expected one path of 11 points, got %v\n", paths)
+	}
+	if stats.Segments != 20 || stats.Removed != 10 || stats.Paths != 1 {
+		t.Errorf("Expected 20 segments, 10 removed, 1 path, got %+v\n", stats)
+	}
+	if stats.TravelAfter != 0 {
+		t.Errorf("Expected %f, got %f\n", 0.0, stats.TravelAfter)
+	}
+	if stats.TravelBefore <= stats.TravelAfter {
+		t.Errorf("Expected travel to go down, got %f to %f\n", stats.TravelBefore, stats.TravelAfter)
+	}
+}
@@ -0,0 +1,154 @@
+// Package sdf defines signed distance functions
+package sdf
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+//////////////////////////////
+// Distance operators
+//////////////////////////////
+
+// SmoothMin blends two distances together like Min (union), using a polynomial smooth minimum.
+// k is the size of the blended region.
+func SmoothMin(a, b, k float64) float64 {
+	if k <= 0 {
+		return math.Min(a, b)
+	}
+	h := math.Max(k-math.Abs(a-b), 0) / k
+	return math.Min(a, b) - h*h*k*0.25
+}
+
+// SmoothMax blends two distances together like Max (intersection), using a polynomial smooth maximum.
+// k is the size of the blended region.
+func SmoothMax(a, b, k float64) float64 {
+	return -SmoothMin(-a, -b, k)
+}
+
+// SmoothMinExp blends two distances together like Min (union), using an exponential smooth minimum.
+// The blend is softer and reaches further than SmoothMin. k is the size of the blended region.
+func SmoothMinExp(a, b, k float64) float64 {
+	if k <= 0 {
+		return math.Min(a, b)
+	}
+	// same as -k * log(exp(-a/k) + exp(-b/k)), without overflowing.
+	return math.Min(a, b) - k*math.Log1p(math.Exp(-math.Abs(a-b)/k))
+}
+
+// SmoothMaxExp blends two distances together like Max (intersection), using an exponential smooth maximum.
+// k is the size of the blended region.
+func SmoothMaxExp(a, b, k float64) float64 {
+	return -SmoothMinExp(-a, -b, k)
+}
+
+// Subtract cuts shape b out of shape a.
+func Subtract(a, b float64) float64 {
+	return math.Max(a, -b)
+}
+
+// SmoothSubtract cuts shape b out of shape a, blending the edges over a region of size k.
+func SmoothSubtract(a, b, k float64) float64 {
+	return SmoothMax(a, -b, k)
+}
+
+// Xor keeps the areas inside either shape a or shape b, but not both.
+func Xor(a, b float64) float64 {
+	return math.Max(math.Min(a, b), -math.Max(a, b))
+}
+
+// Round rounds off a shape by growing it by radius r.
+func Round(d, r float64) float64 {
+	return d - r
+}
+
+// Annular turns a shape into a ring around its edge, with the given thickness on each side.
+func Annular(d, thickness float64) float64 {
+	return math.Abs(d) - thickness
+}
+
+// Onion turns a shape into concentric shells.
+// Each layer splits every shell into two, each half as thick.
+func Onion(d, thickness float64, layers int) float64 {
+	for i := 0; i < layers; i++ {
+		d = math.Abs(d) - thickness
+		thickness /= 2
+	}
+	return d
+}
+
+//////////////////////////////
+// Domain operators
+// These transform the x, y coords before they are passed to a distance function.
+//////////////////////////////
+
+// Rotate rotates the domain around the origin, so a shape evaluated with the result is rotated by angle.
+func Rotate(x, y, angle float64) (float64, float64) {
+	cos := math.Cos(angle)
+	sin := math.Sin(angle)
+	return x*cos + y*sin, y*cos - x*sin
+}
+
+// RotateFrom rotates the domain around the given cx, cy location.
+func RotateFrom(x, y, cx, cy, angle float64) (float64, float64) {
+	x, y = Rotate(x-cx, y-cy, angle)
+	return x + cx, y + cy
+}
+
+// Scale scales the domain around the origin, so a shape evaluated with the result is scaled by s.
+// The distance returned by the shape must then be multiplied by s.
+func Scale(x, y, s float64) (float64, float64) {
+	return x / s, y / s
+}
+
+// ScaleFrom scales the domain around the given cx, cy location.
+// The distance returned by the shape must then be multiplied by s.
+func ScaleFrom(x, y, cx, cy, s float64) (float64, float64) {
+	return (x-cx)/s + cx, (y-cy)/s + cy
+}
+
+// MirrorX mirrors the domain across a vertical line at cx, so the right side is reflected to the left.
+func MirrorX(x, cx float64) float64 {
+	return cx + math.Abs(x-cx)
+}
+
+// MirrorY mirrors the domain across a horizontal line at cy, so the bottom is reflected to the top.
+func MirrorY(y, cy float64) float64 {
+	return cy + math.Abs(y-cy)
+}
+
+// Mirror mirrors the domain across a line through cx, cy at the given angle.
+// Points on the right of the line (looking along the angle) are reflected to the left side.
+func Mirror(x, y, cx, cy, angle float64) (float64, float64) {
+	nx := -math.Sin(angle)
+	ny := math.Cos(angle)
+	d := (x-cx)*nx + (y-cy)*ny
+	if d < 0 {
+		x -= 2 * d * nx
+		y -= 2 * d * ny
+	}
+	return x, y
+}
+
+// RepeatLimited repeats a single axis of the domain every dist units, like Repeat, but
+// only for cells min through max. The cells are the same as Repeat's, so cell 0 runs from 0 to dist,
+// and a shape centered on 0 is repeated at (min + 0.5) * dist through (max + 0.5) * dist.
+func RepeatLimited(val, dist, min, max float64) float64 {
+	return val - dist*(blmath.Clamp(math.Floor(val/dist), min, max)+0.5)
+}
+
+// RepeatPolar repeats the domain around the origin count times.
+// A shape positioned along the positive x axis will be repeated in a circle.
+func RepeatPolar(x, y float64, count int) (float64, float64) {
+	sector := blmath.Tau / float64(count)
+	angle := blmath.ModPos(math.Atan2(y, x)+sector/2, sector) - sector/2
+	radius := math.Hypot(x, y)
+	return math.Cos(angle) * radius, math.Sin(angle) * radius
+}
+
+// RepeatPolarFrom repeats the domain around the given cx, cy location count times.
+func RepeatPolarFrom(x, y, cx, cy float64, count int) (float64, float64) {
+	x, y = RepeatPolar(x-cx, y-cy, count)
+	return x + cx, y + cy
+}
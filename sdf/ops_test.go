@@ -0,0 +1,78 @@
+// Package sdf defines signed distance functions
+package sdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+)
+
+func TestDistanceOperators(t *testing.T) {
+	type test struct {
+		name     string
+		result   float64
+		expected float64
+	}
+	tests := []test{
+		{"SmoothMin far apart", SmoothMin(1, 5, 2), 1},
+		{"SmoothMin equal", SmoothMin(1, 1, 2), 0.5},
+		{"SmoothMax far apart", SmoothMax(1, 5, 2), 5},
+		{"SmoothMinExp no blend", SmoothMinExp(1, 5, 0), 1},
+		{"SmoothMinExp equal", SmoothMinExp(1, 1, 2), 1 - 2*math.Log(2)},
+		{"Subtract", Subtract(-3, -1), 1},
+		{"Xor both inside", Xor(-3, -1), 1},
+		{"Xor one inside", Xor(-3, 2), -2},
+		{"Round", Round(3, 1), 2},
+		{"Annular", Annular(-3, 1), 2},
+		{"Onion", Onion(-3, 2, 2), 0},
+	}
+	for _, test := range tests {
+		if !blmath.Equalish(test.result, test.expected, 1e-9) {
+			t.Errorf("%s: Expected %f, got %f\n", test.name, test.expected, test.result)
+		}
+	}
+}
+
+func TestDomainOperators(t *testing.T) {
+	// rotating a point by a quarter turn moves it back a quarter turn in the domain.
+	x, y := Rotate(10, 0, math.Pi/2)
+	if !blmath.Equalish(x, 0, 1e-9) || !blmath.Equalish(y, -10, 1e-9) {
+		t.Errorf("Expected 0, -10, got %f, %f\n", x, y)
+	}
+	x, y = RotateFrom(20, 10, 10, 10, math.Pi)
+	if !blmath.Equalish(x, 0, 1e-9) || !blmath.Equalish(y, 10, 1e-9) {
+		t.Errorf("Expected 0, 10, got %f, %f\n", x, y)
+	}
+	x, y = ScaleFrom(30, 10, 10, 10, 2)
+	if x != 20 || y != 10 {
+		t.Errorf("Expected 20, 10, got %f, %f\n", x, y)
+	}
+	if MirrorX(4, 5) != 6 || MirrorX(6, 5) != 6 {
+		t.Errorf("Expected MirrorX to reflect to the right side\n")
+	}
+	x, y = Mirror(0, -5, 0, 0, 0)
+	if !blmath.Equalish(x, 0, 1e-9) || !blmath.Equalish(y, 5, 1e-9) {
+		t.Errorf("Expected 0, 5, got %f, %f\n", x, y)
+	}
+	// cells -1 through 1 cover -10 to 20, and are the same as Repeat's.
+	for val := -9.5; val < 20; val += 1.5 {
+		if !blmath.Equalish(RepeatLimited(val, 10, -1, 1), Repeat(val, 10), 1e-9) {
+			t.Errorf("Expected %f, got %f\n", Repeat(val, 10), RepeatLimited(val, 10, -1, 1))
+		}
+	}
+	if RepeatLimited(23, 10, -1, 1) != 8 || RepeatLimited(-13, 10, -1, 1) != -8 {
+		t.Errorf("Expected RepeatLimited to stop at the last cell\n")
+	}
+
+	// every copy of a circle on the x axis should be the same distance away.
+	d0 := Circle(10, 0, 10, 0, 2)
+	for i := range 6 {
+		angle := float64(i) / 6 * 2 * math.Pi
+		px, py := RepeatPolar(math.Cos(angle)*10, math.Sin(angle)*10, 6)
+		d := Circle(px, py, 10, 0, 2)
+		if !blmath.Equalish(d, d0, 1e-9) {
+			t.Errorf("Expected %f, got %f\n", d0, d)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package sdf defines signed distance functions
+package sdf
+
+import (
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/bit101/bitlib/bitmap"
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/bitlib/blmath"
+)
+
+// DistFunc is a signed distance function. It returns a negative value inside the shape,
+// positive outside and zero on the edge.
+type DistFunc func(x, y float64) float64
+
+// RenderFill fills the inside of a distance function onto a bitmap, with anti-aliased edges.
+// The function is evaluated in pixel coordinates at the center of each pixel.
+func RenderFill(bmp *bitmap.Bitmap, f DistFunc, color blcolor.Color) {
+	render(bmp, f, color, func(d, fw float64) float64 {
+		return edgeCoverage(d, fw)
+	})
+}
+
+// RenderStroke strokes the zero edge of a distance function onto a bitmap, with the given line width.
+func RenderStroke(bmp *bitmap.Bitmap, f DistFunc, color blcolor.Color, width float64) {
+	render(bmp, f, color, func(d, fw float64) float64 {
+		return edgeCoverage(math.Abs(d)-width/2, fw)
+	})
+}
+
+// RenderGlow fills the inside of a distance function and adds a glow that fades out over the given radius.
+// A radius of 0 or less is the same as RenderFill.
+func RenderGlow(bmp *bitmap.Bitmap, f DistFunc, color blcolor.Color, radius float64) {
+	if radius <= 0 {
+		RenderFill(bmp, f, color)
+		return
+	}
+	render(bmp, f, color, func(d, fw float64) float64 {
+		if d <= 0 {
+			return 1
+		}
+		return 1 - smoothstep(0, radius, d)
+	})
+}
+
+// RenderContours draws iso lines of a distance function every spacing units, with the given line width.
+func RenderContours(bmp *bitmap.Bitmap, f DistFunc, color blcolor.Color, spacing, width float64) {
+	render(bmp, f, color, func(d, fw float64) float64 {
+		m := math.Abs(d - spacing*math.Round(d/spacing))
+		return edgeCoverage(m-width/2, fw)
+	})
+}
+
+// RenderBands fills alternating bands of a distance function, each spacing units wide.
+// The band just inside the zero edge is filled.
+func RenderBands(bmp *bitmap.Bitmap, f DistFunc, color blcolor.Color, spacing float64) {
+	render(bmp, f, color, func(d, fw float64) float64 {
+		// u is the position within a pair of bands. [0, spacing) is empty, [spacing, spacing*2) is filled.
+		u := blmath.ModPos(d, spacing*2)
+		if u < spacing {
+			return edgeCoverage(spacing/2-math.Abs(u-spacing/2), fw)
+		}
+		return edgeCoverage(math.Abs(u-spacing*1.5)-spacing/2, fw)
+	})
+}
+
+// render evaluates the distance function for each pixel in parallel rows and blends the color
+// onto the bitmap using the coverage returned by the given func.
+// The coverage func gets the distance and the pixel footprint - how much the distance changes across one pixel.
+func render(bmp *bitmap.Bitmap, f DistFunc, color blcolor.Color, coverage func(d, fw float64) float64) {
+	rows := make(chan int, bmp.Height)
+	for y := 0; y < bmp.Height; y++ {
+		rows <- y
+	}
+	close(rows)
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				py := float64(y) + 0.5
+				for x := 0; x < bmp.Width; x++ {
+					px := float64(x) + 0.5
+					d := f(px, py)
+					a := coverage(d, footprint(f, px, py)) * color.A
+					if a > 0 {
+						bmp.BlendPixel(x, y, color.R, color.G, color.B, a)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// footprint estimates how much a distance function changes across one pixel, using central differences.
+// This is 1 for an exact euclidean distance, but scaled or warped fields differ.
+func footprint(f DistFunc, x, y float64) float64 {
+	dx := f(x+0.5, y) - f(x-0.5, y)
+	dy := f(x, y+0.5) - f(x, y-0.5)
+	fw := math.Hypot(dx, dy)
+	if fw < 1e-6 {
+		return 1
+	}
+	return fw
+}
+
+// edgeCoverage returns the anti-aliased coverage of a pixel at the given distance from an edge.
+func edgeCoverage(d, fw float64) float64 {
+	return 1 - smoothstep(-fw/2, fw/2, d)
+}
+
+// smoothstep is the hermite interpolation of x between edge0 and edge1.
+func smoothstep(edge0, edge1, x float64) float64 {
+	t := blmath.Clamp((x-edge0)/(edge1-edge0), 0, 1)
+	return t * t * (3 - 2*t)
+}
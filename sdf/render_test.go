@@ -0,0 +1,62 @@
+// Package sdf defines signed distance functions
+package sdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/bitmap"
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/bitlib/blmath"
+)
+
+// coverage returns the total alpha of a bitmap.
+func coverage(bmp *bitmap.Bitmap) float64 {
+	total := 0.0
+	for y := 0; y < bmp.Height; y++ {
+		for x := 0; x < bmp.Width; x++ {
+			_, _, _, a := bmp.GetPixelRGBA(x, y)
+			total += a
+		}
+	}
+	return total
+}
+
+func TestRenderFill(t *testing.T) {
+	bmp := bitmap.NewBitmap(100, 100)
+	bmp.ClearRGBA(0, 0, 0, 0)
+	RenderFill(bmp, func(x, y float64) float64 {
+		return Circle(x, y, 50, 50, 30)
+	}, blcolor.RGB(1, 1, 1))
+
+	total := coverage(bmp)
+	expected := math.Pi * 30 * 30
+	if !blmath.Equalish(total, expected, expected*0.005) {
+		t.Errorf("Expected %f, got %f\n", expected, total)
+	}
+	_, _, _, a := bmp.GetPixelRGBA(50, 50)
+	if a != 1 {
+		t.Errorf("Expected %f, got %f\n", 1.0, a)
+	}
+	_, _, _, a = bmp.GetPixelRGBA(5, 5)
+	if a != 0 {
+		t.Errorf("Expected %f, got %f\n", 0.0, a)
+	}
+}
+
+func TestRenderGlowZeroRadius(t *testing.T) {
+	f := func(x, y float64) float64 {
+		return Circle(x, y, 20, 20, 10)
+	}
+	fill := bitmap.NewBitmap(40, 40)
+	fill.ClearRGBA(0, 0, 0, 0)
+	RenderFill(fill, f, blcolor.RGB(1, 1, 1))
+	glow := bitmap.NewBitmap(40, 40)
+	glow.ClearRGBA(0, 0, 0, 0)
+	RenderGlow(glow, f, blcolor.RGB(1, 1, 1), 0)
+
+	total := coverage(glow)
+	if math.IsNaN(total) || total != coverage(fill) {
+		t.Errorf("Expected %f, got %f\n", coverage(fill), total)
+	}
+}
@@ -26,6 +26,8 @@ func Max(shapes ...float64) float64 {
 	return max
 }
 
+// Repeat repeats a single axis of the domain every dist units. Cell k runs from k * dist to (k + 1) * dist,
+// and its center maps to 0, so a shape centered on 0 is repeated at (k + 0.5) * dist.
 func Repeat(val, dist float64) float64 {
 	val /= dist
 	val -= math.Floor(val) + 0.5
@@ -75,28 +77,258 @@ func Segment(x, y, ax, ay, bx, by float64) float64 {
 	return pa.Subtract(ba.Scaled(h)).Magnitude()
 }
 
-// float sdSegment( in vec2 p, in vec2 a, in vec2 b )
-// {
-//     vec2 pa = p-a, ba = b-a;
-//     float h = clamp( dot(pa,ba)/dot(ba,ba), 0.0, 1.0 );
-//     return length( pa - ba*h );
-// }
-// func OrientedBox(x, y, ax, ay, bx, by, bw, bh, t float64) float64 {
-// 	p := geom.NewVector(x-bx, y-by)
-// 	a := geom.NewVector(ax, ay)
-// 	b := geom.NewVector(bw, bh)
-// 	l := b.Subtract(a).Magnitude()
-// 	q := p.Subtract(a.Add(b).Scaled(0.5))
-// 	q =
-
-// }
-
-// float sdOrientedBox( in vec2 p, in vec2 a, in vec2 b, float th )
-// {
-//     float l = length(b-a);
-//     vec2  d = (b-a)/l;
-//     vec2  q = (p-(a+b)*0.5);
-//           q = mat2(d.x,-d.y,d.y,d.x)*q;
-//           q = abs(q)-vec2(l,th)*0.5;
-//     return length(max(q,0.0)) + min(max(q.x,q.y),0.0);
-// }
+// OrientedBox computes the signed distance to a box running from point a to point b, with the given thickness.
+func OrientedBox(x, y, ax, ay, bx, by, th float64) float64 {
+	l := math.Hypot(bx-ax, by-ay)
+	dx := (bx - ax) / l
+	dy := (by - ay) / l
+	qx := x - (ax+bx)*0.5
+	qy := y - (ay+by)*0.5
+	qx, qy = dx*qx+dy*qy, -dy*qx+dx*qy
+	qx = math.Abs(qx) - l*0.5
+	qy = math.Abs(qy) - th*0.5
+	return math.Hypot(math.Max(qx, 0), math.Max(qy, 0)) + math.Min(math.Max(qx, qy), 0)
+}
+
+// Ellipse computes the signed distance to an axis-aligned ellipse with radii rx and ry.
+func Ellipse(x, y, cx, cy, rx, ry float64) float64 {
+	px := math.Abs(x - cx)
+	py := math.Abs(y - cy)
+	if rx == ry {
+		return math.Hypot(px, py) - rx
+	}
+	// iteratively find the closest point on the ellipse, parameterized by (tx, ty) on the unit circle.
+	tx, ty := math.Sqrt2/2, math.Sqrt2/2
+	for i := 0; i < 4; i++ {
+		ex := (rx*rx - ry*ry) * tx * tx * tx / rx
+		ey := (ry*ry - rx*rx) * ty * ty * ty / ry
+		r := math.Hypot(rx*tx-ex, ry*ty-ey)
+		q := math.Hypot(px-ex, py-ey)
+		if q == 0 {
+			break
+		}
+		tx = blmath.Clamp(((px-ex)*r/q+ex)/rx, 0, 1)
+		ty = blmath.Clamp(((py-ey)*r/q+ey)/ry, 0, 1)
+		t := math.Hypot(tx, ty)
+		tx /= t
+		ty /= t
+	}
+	d := math.Hypot(px-rx*tx, py-ry*ty)
+	if (px*px)/(rx*rx)+(py*py)/(ry*ry) < 1 {
+		return -d
+	}
+	return d
+}
+
+// Triangle computes the signed distance to the triangle with the given three corners.
+func Triangle(x, y, x0, y0, x1, y1, x2, y2 float64) float64 {
+	return polygon(x, y, []float64{x0, x1, x2}, []float64{y0, y1, y2})
+}
+
+// Polygon computes the signed distance to an arbitrary closed polygon.
+// The polygon may be concave but should not be self intersecting. The last point can repeat the first or not.
+// An empty polygon is infinitely far away.
+func Polygon(x, y float64, points geom.PointList) float64 {
+	xs := make([]float64, len(points))
+	ys := make([]float64, len(points))
+	for i, p := range points {
+		xs[i] = p.X
+		ys[i] = p.Y
+	}
+	return polygon(x, y, xs, ys)
+}
+
+// polygon computes the signed distance to the polygon made of the given vertex coords.
+func polygon(x, y float64, xs, ys []float64) float64 {
+	count := len(xs)
+	if count == 0 {
+		return math.MaxFloat64
+	}
+	d := (x-xs[0])*(x-xs[0]) + (y-ys[0])*(y-ys[0])
+	s := 1.0
+	for i, j := 0, count-1; i < count; j, i = i, i+1 {
+		ex := xs[j] - xs[i]
+		ey := ys[j] - ys[i]
+		if ex == 0 && ey == 0 {
+			// a repeated point, such as a closing point, has no edge and can't be crossed.
+			continue
+		}
+		wx := x - xs[i]
+		wy := y - ys[i]
+		h := blmath.Clamp((wx*ex+wy*ey)/(ex*ex+ey*ey), 0, 1)
+		bx := wx - ex*h
+		by := wy - ey*h
+		d = math.Min(d, bx*bx+by*by)
+
+		// winding test, flipping the sign for each edge crossed by a ray from the point.
+		c0 := y >= ys[i]
+		c1 := y < ys[j]
+		c2 := ex*wy > ey*wx
+		if (c0 && c1 && c2) || (!c0 && !c1 && !c2) {
+			s = -s
+		}
+	}
+	return s * math.Sqrt(d)
+}
+
+// RegularPolygon computes the signed distance to a regular polygon with the given number of sides.
+// radius is the distance from the center to each corner. rotation is the angle of the first corner.
+func RegularPolygon(x, y, cx, cy, radius float64, sides int, rotation float64) float64 {
+	return Star(x, y, cx, cy, radius, sides, 2, rotation)
+}
+
+// Star computes the signed distance to a star with the given number of points.
+// radius is the distance from the center to each point. rotation is the angle of the first point.
+// m controls how sharp the points are, from 2 (a regular polygon) up to the number of points.
+func Star(x, y, cx, cy, radius float64, points int, m, rotation float64) float64 {
+	px, py := toLocal(x-cx, y-cy, rotation)
+	an := math.Pi / float64(points)
+	en := math.Pi / m
+	acx, acy := math.Cos(an), math.Sin(an)
+	ecx, ecy := math.Cos(en), math.Sin(en)
+
+	// reduce to the first sector
+	bn := blmath.ModPos(math.Atan2(px, py), 2*an) - an
+	l := math.Hypot(px, py)
+	px = l * math.Cos(bn)
+	py = l * math.Abs(math.Sin(bn))
+
+	// distance to the edge line
+	px -= radius * acx
+	py -= radius * acy
+	h := blmath.Clamp(-(px*ecx + py*ecy), 0, radius*acy/ecy)
+	px += ecx * h
+	py += ecy * h
+	return math.Hypot(px, py) * sign(px)
+}
+
+// Arc computes the signed distance to a circular arc from the start angle to the end angle, with the given width.
+func Arc(x, y, cx, cy, radius, start, end, width float64) float64 {
+	px, py, sc, cc := arcLocal(x-cx, y-cy, start, end)
+	if cc*px > sc*py {
+		return math.Hypot(px-sc*radius, py-cc*radius) - width/2
+	}
+	return math.Abs(math.Hypot(px, py)-radius) - width/2
+}
+
+// Pie computes the signed distance to a pie wedge of a circle from the start angle to the end angle.
+func Pie(x, y, cx, cy, radius, start, end float64) float64 {
+	px, py, sc, cc := arcLocal(x-cx, y-cy, start, end)
+	l := math.Hypot(px, py) - radius
+	h := blmath.Clamp(px*sc+py*cc, 0, radius)
+	m := math.Hypot(px-sc*h, py-cc*h)
+	return math.Max(l, m*sign(cc*px-sc*py))
+}
+
+// arcLocal transforms a point so that the middle of an arc points along +y, folded onto +x.
+// It also returns the sin and cos of half the arc's aperture.
+func arcLocal(x, y, start, end float64) (float64, float64, float64, float64) {
+	half := math.Abs(end-start) / 2
+	x, y = toLocal(x, y, (start+end)/2)
+	return math.Abs(x), y, math.Sin(half), math.Cos(half)
+}
+
+// QuadraticBezier computes the (unsigned) distance to a quadratic Bezier curve
+// from a to c with control point b.
+func QuadraticBezier(x, y, ax, ay, bx, by, cx, cy float64) float64 {
+	// a, b and c here are the polynomial coefficients, not the points.
+	aX, aY := bx-ax, by-ay
+	bX, bY := ax-2*bx+cx, ay-2*by+cy
+	if bX*bX+bY*bY < 1e-12 {
+		// control point in the middle, it's a straight line.
+		return Segment(x, y, ax, ay, cx, cy)
+	}
+	cX, cY := aX*2, aY*2
+	dX, dY := ax-x, ay-y
+
+	kk := 1 / (bX*bX + bY*bY)
+	kx := kk * (aX*bX + aY*bY)
+	ky := kk * (2*(aX*aX+aY*aY) + (dX*bX + dY*bY)) / 3
+	kz := kk * (dX*aX + dY*aY)
+
+	distSq := func(t float64) float64 {
+		qx := dX + (cX+bX*t)*t
+		qy := dY + (cY+bY*t)*t
+		return qx*qx + qy*qy
+	}
+
+	p := ky - kx*kx
+	p3 := p * p * p
+	q := kx*(2*kx*kx-3*ky) + kz
+	h := q*q + 4*p3
+	if h >= 0 {
+		// one real root
+		h = math.Sqrt(h)
+		u := math.Cbrt((h - q) / 2)
+		v := math.Cbrt((-h - q) / 2)
+		t := blmath.Clamp(u+v-kx, 0, 1)
+		return math.Sqrt(distSq(t))
+	}
+	// three real roots, the third can never be the closest.
+	z := math.Sqrt(-p)
+	v := math.Acos(q/(p*z*2)) / 3
+	m := math.Cos(v)
+	n := math.Sin(v) * math.Sqrt(3)
+	t0 := blmath.Clamp((m+m)*z-kx, 0, 1)
+	t1 := blmath.Clamp((-n-m)*z-kx, 0, 1)
+	return math.Sqrt(math.Min(distSq(t0), distSq(t1)))
+}
+
+// Vesica computes the signed distance to a vertical vesica (lens) shape,
+// the intersection of two circles of radius r whose centers are d to each side of the center.
+// d must be less than r.
+func Vesica(x, y, cx, cy, r, d float64) float64 {
+	px := math.Abs(x - cx)
+	py := math.Abs(y - cy)
+	b := math.Sqrt(r*r - d*d)
+	if (py-b)*d > px*b {
+		return math.Hypot(px, py-b)
+	}
+	return math.Hypot(px+d, py) - r
+}
+
+// Cross computes the signed distance to a plus shaped cross.
+// length is the distance from the center to the end of each arm, thickness is half the arm's width.
+// r rounds the shape.
+func Cross(x, y, cx, cy, length, thickness, r float64) float64 {
+	px := math.Abs(x - cx)
+	py := math.Abs(y - cy)
+	if py > px {
+		px, py = py, px
+	}
+	qx := px - length
+	qy := py - thickness
+	k := math.Max(qx, qy)
+	wx, wy := qx, qy
+	if k <= 0 {
+		wx, wy = thickness-px, -k
+	}
+	return sign(k)*math.Hypot(math.Max(wx, 0), math.Max(wy, 0)) + r
+}
+
+// RoundedX computes the signed distance to an X shape of the given width, with rounded arms of radius r.
+func RoundedX(x, y, cx, cy, width, r float64) float64 {
+	px := math.Abs(x - cx)
+	py := math.Abs(y - cy)
+	m := math.Min(px+py, width) * 0.5
+	return math.Hypot(px-m, py-m) - r
+}
+
+// toLocal rotates a point so that the given angle lies along the +y axis.
+func toLocal(x, y, angle float64) (float64, float64) {
+	a := math.Pi/2 - angle
+	cos := math.Cos(a)
+	sin := math.Sin(a)
+	return x*cos - y*sin, x*sin + y*cos
+}
+
+// sign returns -1, 0 or 1 depending on the sign of the value.
+func sign(val float64) float64 {
+	if val < 0 {
+		return -1
+	}
+	if val > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,46 @@
+// Package sdf defines signed distance functions
+package sdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+	"github.com/bit101/bitlib/geom"
+)
+
+func TestPolygon(t *testing.T) {
+	type test struct {
+		points   geom.PointList
+		x, y     float64
+		expected float64
+	}
+	square := geom.PointList{geom.NewPoint(0, 0), geom.NewPoint(10, 0), geom.NewPoint(10, 10), geom.NewPoint(0, 10)}
+	closed := append(square.Clone(), geom.NewPoint(0, 0))
+	repeated := geom.PointList{geom.NewPoint(0, 0), geom.NewPoint(10, 0), geom.NewPoint(10, 0), geom.NewPoint(10, 10), geom.NewPoint(0, 10)}
+	tests := []test{
+		{square, 5, 5, -5},
+		{square, 15, 5, 5},
+		{square, 13, 14, 5},
+		{closed, 5, 5, -5},
+		{closed, 15, 5, 5},
+		{closed, -3, -4, 5},
+		{repeated, 5, 5, -5},
+		{repeated, 12, 5, 2},
+	}
+	for _, test := range tests {
+		d := Polygon(test.x, test.y, test.points)
+		if !blmath.Equalish(d, test.expected, 1e-9) {
+			t.Errorf("Expected %f, got %f\n", test.expected, d)
+		}
+		d = NewPolygon(test.points).Distance(test.x, test.y)
+		if !blmath.Equalish(d, test.expected, 1e-9) {
+			t.Errorf("Expected %f, got %f\n", test.expected, d)
+		}
+	}
+
+	d := Polygon(5, 5, geom.NewPointList())
+	if math.IsNaN(d) || d <= 0 {
+		t.Errorf("Expected empty polygon to be outside, got %f\n", d)
+	}
+}
@@ -0,0 +1,122 @@
+// Package sdf defines signed distance functions
+package sdf
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+// Shape is a signed distance field that can be combined with other shapes into a tree.
+// Primitives, operators and domain transforms all implement Shape.
+type Shape interface {
+	// Distance returns the signed distance from the given point to the shape.
+	Distance(x, y float64) float64
+	// Gradient returns the direction in which the distance increases fastest at the given point.
+	// For exact distance fields this has a length of 1.
+	Gradient(x, y float64) (float64, float64)
+	// Bounds returns a rectangle enclosing the inside of the shape, or nil if the shape is unbounded.
+	Bounds() *geom.Rect
+}
+
+// gradientEpsilon is the step used when estimating gradients by finite differences.
+const gradientEpsilon = 1e-4
+
+// NumericGradient estimates the gradient of a distance function at the given point using central differences.
+func NumericGradient(f DistFunc, x, y float64) (float64, float64) {
+	dx := f(x+gradientEpsilon, y) - f(x-gradientEpsilon, y)
+	dy := f(x, y+gradientEpsilon) - f(x, y-gradientEpsilon)
+	return dx / (2 * gradientEpsilon), dy / (2 * gradientEpsilon)
+}
+
+// BoundsDistance returns a lower bound of the distance from a point to any shape within the given bounds.
+// It returns 0 if the point is inside the bounds, or if bounds is nil.
+func BoundsDistance(bounds *geom.Rect, x, y float64) float64 {
+	if bounds == nil {
+		return 0
+	}
+	dx := math.Max(math.Max(bounds.X-x, x-(bounds.X+bounds.W)), 0)
+	dy := math.Max(math.Max(bounds.Y-y, y-(bounds.Y+bounds.H)), 0)
+	return math.Hypot(dx, dy)
+}
+
+//////////////////////////////
+// Custom shapes
+//////////////////////////////
+
+type funcShape struct {
+	f      DistFunc
+	bounds *geom.Rect
+}
+
+// NewFunc creates a shape from any distance function, with an optional bounds rect (nil for unbounded).
+// The gradient is estimated numerically.
+func NewFunc(f DistFunc, bounds *geom.Rect) Shape {
+	return &funcShape{f, bounds}
+}
+
+func (s *funcShape) Distance(x, y float64) float64 {
+	return s.f(x, y)
+}
+
+func (s *funcShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.f, x, y)
+}
+
+func (s *funcShape) Bounds() *geom.Rect {
+	return s.bounds
+}
+
+//////////////////////////////
+// Bounds helpers
+//////////////////////////////
+
+// unionBounds returns a rect enclosing both rects, or nil if either is nil.
+func unionBounds(a, b *geom.Rect) *geom.Rect {
+	if a == nil || b == nil {
+		return nil
+	}
+	x0 := math.Min(a.X, b.X)
+	y0 := math.Min(a.Y, b.Y)
+	x1 := math.Max(a.X+a.W, b.X+b.W)
+	y1 := math.Max(a.Y+a.H, b.Y+b.H)
+	return geom.NewRect(x0, y0, x1-x0, y1-y0)
+}
+
+// intersectBounds returns the overlap of two rects. A nil rect is unbounded, so the other is returned.
+// Rects that don't overlap give an empty rect.
+func intersectBounds(a, b *geom.Rect) *geom.Rect {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	x0 := math.Max(a.X, b.X)
+	y0 := math.Max(a.Y, b.Y)
+	x1 := math.Max(x0, math.Min(a.X+a.W, b.X+b.W))
+	y1 := math.Max(y0, math.Min(a.Y+a.H, b.Y+b.H))
+	return geom.NewRect(x0, y0, x1-x0, y1-y0)
+}
+
+// expandBounds grows a rect by the given amount on each side.
+func expandBounds(r *geom.Rect, amount float64) *geom.Rect {
+	if r == nil {
+		return nil
+	}
+	return geom.NewRect(r.X-amount, r.Y-amount, r.W+amount*2, r.H+amount*2)
+}
+
+// pointBounds returns the rect enclosing the given x, y pairs.
+func pointBounds(coords ...float64) *geom.Rect {
+	points := geom.NewPointList()
+	for i := 0; i < len(coords); i += 2 {
+		points.AddXY(coords[i], coords[i+1])
+	}
+	return points.BoundingBox()
+}
+
+// circleBounds returns the rect enclosing a circle.
+func circleBounds(cx, cy, radius float64) *geom.Rect {
+	return geom.NewRect(cx-radius, cy-radius, radius*2, radius*2)
+}
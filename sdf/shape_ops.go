@@ -0,0 +1,449 @@
+// Package sdf defines signed distance functions
+package sdf
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+//////////////////////////////
+// Boolean operators
+//////////////////////////////
+
+type unionShape struct {
+	shapes []Shape
+	bounds *geom.Rect
+}
+
+// NewUnion creates a shape that is the union of all the given shapes.
+// Children whose bounds are further away than the closest shape found so far are skipped.
+func NewUnion(shapes ...Shape) Shape {
+	var bounds *geom.Rect
+	for i, s := range shapes {
+		if i == 0 {
+			bounds = s.Bounds()
+		} else {
+			bounds = unionBounds(bounds, s.Bounds())
+		}
+	}
+	return &unionShape{shapes, bounds}
+}
+
+// closest returns the child with the lowest distance and that distance.
+func (s *unionShape) closest(x, y float64) (Shape, float64) {
+	var closest Shape
+	dist := math.Inf(1)
+	for _, child := range s.shapes {
+		// outside its bounds, a child can't be closer than the bounds themselves.
+		if bd := BoundsDistance(child.Bounds(), x, y); bd > 0 && bd >= dist {
+			continue
+		}
+		d := child.Distance(x, y)
+		if d < dist {
+			closest = child
+			dist = d
+		}
+	}
+	return closest, dist
+}
+
+func (s *unionShape) Distance(x, y float64) float64 {
+	_, d := s.closest(x, y)
+	return d
+}
+
+func (s *unionShape) Gradient(x, y float64) (float64, float64) {
+	closest, _ := s.closest(x, y)
+	if closest == nil {
+		return 0, 0
+	}
+	return closest.Gradient(x, y)
+}
+
+func (s *unionShape) Bounds() *geom.Rect {
+	return s.bounds
+}
+
+type intersectionShape struct {
+	shapes []Shape
+	bounds *geom.Rect
+}
+
+// NewIntersection creates a shape that is the intersection of all the given shapes.
+func NewIntersection(shapes ...Shape) Shape {
+	var bounds *geom.Rect
+	for _, s := range shapes {
+		bounds = intersectBounds(bounds, s.Bounds())
+	}
+	return &intersectionShape{shapes, bounds}
+}
+
+// furthest returns the child with the highest distance and that distance.
+func (s *intersectionShape) furthest(x, y float64) (Shape, float64) {
+	var furthest Shape
+	dist := math.Inf(-1)
+	for _, child := range s.shapes {
+		d := child.Distance(x, y)
+		if d > dist {
+			furthest = child
+			dist = d
+		}
+	}
+	return furthest, dist
+}
+
+func (s *intersectionShape) Distance(x, y float64) float64 {
+	_, d := s.furthest(x, y)
+	return d
+}
+
+func (s *intersectionShape) Gradient(x, y float64) (float64, float64) {
+	furthest, _ := s.furthest(x, y)
+	if furthest == nil {
+		return 0, 0
+	}
+	return furthest.Gradient(x, y)
+}
+
+func (s *intersectionShape) Bounds() *geom.Rect {
+	return s.bounds
+}
+
+type subtractionShape struct {
+	a, b Shape
+}
+
+// NewSubtraction creates a shape that is shape b cut out of shape a.
+func NewSubtraction(a, b Shape) Shape {
+	return &subtractionShape{a, b}
+}
+
+func (s *subtractionShape) Distance(x, y float64) float64 {
+	return Subtract(s.a.Distance(x, y), s.b.Distance(x, y))
+}
+
+func (s *subtractionShape) Gradient(x, y float64) (float64, float64) {
+	da := s.a.Distance(x, y)
+	db := s.b.Distance(x, y)
+	if da >= -db {
+		return s.a.Gradient(x, y)
+	}
+	gx, gy := s.b.Gradient(x, y)
+	return -gx, -gy
+}
+
+func (s *subtractionShape) Bounds() *geom.Rect {
+	return s.a.Bounds()
+}
+
+type xorShape struct {
+	a, b Shape
+}
+
+// NewXor creates a shape covering the areas inside either shape a or shape b, but not both.
+func NewXor(a, b Shape) Shape {
+	return &xorShape{a, b}
+}
+
+func (s *xorShape) Distance(x, y float64) float64 {
+	return Xor(s.a.Distance(x, y), s.b.Distance(x, y))
+}
+
+func (s *xorShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *xorShape) Bounds() *geom.Rect {
+	return unionBounds(s.a.Bounds(), s.b.Bounds())
+}
+
+//////////////////////////////
+// Smooth operators
+//////////////////////////////
+
+type smoothShape struct {
+	a, b   Shape
+	k      float64
+	f      func(a, b, k float64) float64
+	bounds *geom.Rect
+}
+
+// NewSmoothUnion creates a shape that blends shapes a and b together like NewUnion, over a region of size k.
+func NewSmoothUnion(a, b Shape, k float64) Shape {
+	// the smooth minimum can push the edge out by up to k / 4.
+	bounds := expandBounds(unionBounds(a.Bounds(), b.Bounds()), k/4)
+	return &smoothShape{a, b, k, SmoothMin, bounds}
+}
+
+// NewSmoothIntersection creates a shape that blends shapes a and b together like NewIntersection, over a region of size k.
+func NewSmoothIntersection(a, b Shape, k float64) Shape {
+	return &smoothShape{a, b, k, SmoothMax, intersectBounds(a.Bounds(), b.Bounds())}
+}
+
+// NewSmoothSubtraction creates a shape that cuts shape b out of shape a, blending the edges over a region of size k.
+func NewSmoothSubtraction(a, b Shape, k float64) Shape {
+	return &smoothShape{a, b, k, SmoothSubtract, a.Bounds()}
+}
+
+func (s *smoothShape) Distance(x, y float64) float64 {
+	return s.f(s.a.Distance(x, y), s.b.Distance(x, y), s.k)
+}
+
+func (s *smoothShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *smoothShape) Bounds() *geom.Rect {
+	return s.bounds
+}
+
+//////////////////////////////
+// Shape modifiers
+//////////////////////////////
+
+type roundShape struct {
+	shape Shape
+	r     float64
+}
+
+// NewRound creates a shape that rounds off another shape by growing it by radius r.
+func NewRound(shape Shape, r float64) Shape {
+	return &roundShape{shape, r}
+}
+
+func (s *roundShape) Distance(x, y float64) float64 {
+	return Round(s.shape.Distance(x, y), s.r)
+}
+
+func (s *roundShape) Gradient(x, y float64) (float64, float64) {
+	return s.shape.Gradient(x, y)
+}
+
+func (s *roundShape) Bounds() *geom.Rect {
+	return expandBounds(s.shape.Bounds(), math.Max(s.r, 0))
+}
+
+type onionShape struct {
+	shape     Shape
+	thickness float64
+	layers    int
+}
+
+// NewAnnular creates a shape that is a ring around the edge of another shape, with the given thickness on each side.
+func NewAnnular(shape Shape, thickness float64) Shape {
+	return &onionShape{shape, thickness, 1}
+}
+
+// NewOnion creates a shape that turns another shape into concentric shells. See Onion.
+func NewOnion(shape Shape, thickness float64, layers int) Shape {
+	return &onionShape{shape, thickness, layers}
+}
+
+func (s *onionShape) Distance(x, y float64) float64 {
+	return Onion(s.shape.Distance(x, y), s.thickness, s.layers)
+}
+
+func (s *onionShape) Gradient(x, y float64) (float64, float64) {
+	// each layer flips the gradient if the distance was negative.
+	gx, gy := s.shape.Gradient(x, y)
+	d := s.shape.Distance(x, y)
+	thickness := s.thickness
+	for i := 0; i < s.layers; i++ {
+		if d < 0 {
+			gx, gy = -gx, -gy
+		}
+		d = math.Abs(d) - thickness
+		thickness /= 2
+	}
+	return gx, gy
+}
+
+func (s *onionShape) Bounds() *geom.Rect {
+	// each layer grows the outer edge by its thickness.
+	grow := 0.0
+	thickness := s.thickness
+	for i := 0; i < s.layers; i++ {
+		grow += thickness
+		thickness /= 2
+	}
+	return expandBounds(s.shape.Bounds(), grow)
+}
+
+//////////////////////////////
+// Domain transforms
+//////////////////////////////
+
+type translateShape struct {
+	shape  Shape
+	tx, ty float64
+}
+
+// NewTranslate creates a shape that moves another shape by tx, ty.
+func NewTranslate(shape Shape, tx, ty float64) Shape {
+	return &translateShape{shape, tx, ty}
+}
+
+func (s *translateShape) Distance(x, y float64) float64 {
+	return s.shape.Distance(x-s.tx, y-s.ty)
+}
+
+func (s *translateShape) Gradient(x, y float64) (float64, float64) {
+	return s.shape.Gradient(x-s.tx, y-s.ty)
+}
+
+func (s *translateShape) Bounds() *geom.Rect {
+	b := s.shape.Bounds()
+	if b == nil {
+		return nil
+	}
+	return geom.NewRect(b.X+s.tx, b.Y+s.ty, b.W, b.H)
+}
+
+type rotateShape struct {
+	shape         Shape
+	cx, cy, angle float64
+}
+
+// NewRotate creates a shape that rotates another shape by angle around cx, cy.
+func NewRotate(shape Shape, cx, cy, angle float64) Shape {
+	return &rotateShape{shape, cx, cy, angle}
+}
+
+func (s *rotateShape) Distance(x, y float64) float64 {
+	x, y = RotateFrom(x, y, s.cx, s.cy, s.angle)
+	return s.shape.Distance(x, y)
+}
+
+func (s *rotateShape) Gradient(x, y float64) (float64, float64) {
+	x, y = RotateFrom(x, y, s.cx, s.cy, s.angle)
+	gx, gy := s.shape.Gradient(x, y)
+	return Rotate(gx, gy, -s.angle)
+}
+
+func (s *rotateShape) Bounds() *geom.Rect {
+	b := s.shape.Bounds()
+	if b == nil {
+		return nil
+	}
+	coords := []float64{}
+	for _, c := range [][2]float64{{b.X, b.Y}, {b.X + b.W, b.Y}, {b.X + b.W, b.Y + b.H}, {b.X, b.Y + b.H}} {
+		x, y := RotateFrom(c[0], c[1], s.cx, s.cy, -s.angle)
+		coords = append(coords, x, y)
+	}
+	return pointBounds(coords...)
+}
+
+type scaleShape struct {
+	shape         Shape
+	cx, cy, scale float64
+}
+
+// NewScale creates a shape that scales another shape by scale around cx, cy.
+func NewScale(shape Shape, cx, cy, scale float64) Shape {
+	return &scaleShape{shape, cx, cy, scale}
+}
+
+func (s *scaleShape) Distance(x, y float64) float64 {
+	x, y = ScaleFrom(x, y, s.cx, s.cy, s.scale)
+	return s.shape.Distance(x, y) * s.scale
+}
+
+func (s *scaleShape) Gradient(x, y float64) (float64, float64) {
+	x, y = ScaleFrom(x, y, s.cx, s.cy, s.scale)
+	return s.shape.Gradient(x, y)
+}
+
+func (s *scaleShape) Bounds() *geom.Rect {
+	b := s.shape.Bounds()
+	if b == nil {
+		return nil
+	}
+	return geom.NewRect(
+		(b.X-s.cx)*s.scale+s.cx,
+		(b.Y-s.cy)*s.scale+s.cy,
+		b.W*s.scale,
+		b.H*s.scale,
+	)
+}
+
+type mirrorShape struct {
+	shape         Shape
+	cx, cy, angle float64
+}
+
+// NewMirror creates a shape that mirrors another shape across a line through cx, cy at the given angle.
+// The part of the shape on the left of the line (looking along the angle) is reflected to the right side.
+func NewMirror(shape Shape, cx, cy, angle float64) Shape {
+	return &mirrorShape{shape, cx, cy, angle}
+}
+
+func (s *mirrorShape) Distance(x, y float64) float64 {
+	x, y = Mirror(x, y, s.cx, s.cy, s.angle)
+	return s.shape.Distance(x, y)
+}
+
+func (s *mirrorShape) Gradient(x, y float64) (float64, float64) {
+	mx, my := Mirror(x, y, s.cx, s.cy, s.angle)
+	gx, gy := s.shape.Gradient(mx, my)
+	if mx == x && my == y {
+		return gx, gy
+	}
+	// reflect the gradient back across the line.
+	nx := -math.Sin(s.angle)
+	ny := math.Cos(s.angle)
+	d := gx*nx + gy*ny
+	return gx - 2*d*nx, gy - 2*d*ny
+}
+
+func (s *mirrorShape) Bounds() *geom.Rect {
+	b := s.shape.Bounds()
+	if b == nil {
+		return nil
+	}
+	nx := -math.Sin(s.angle)
+	ny := math.Cos(s.angle)
+	coords := []float64{}
+	for _, c := range [][2]float64{{b.X, b.Y}, {b.X + b.W, b.Y}, {b.X + b.W, b.Y + b.H}, {b.X, b.Y + b.H}} {
+		d := (c[0]-s.cx)*nx + (c[1]-s.cy)*ny
+		coords = append(coords, c[0], c[1], c[0]-2*d*nx, c[1]-2*d*ny)
+	}
+	return pointBounds(coords...)
+}
+
+type repeatPolarShape struct {
+	shape  Shape
+	cx, cy float64
+	count  int
+}
+
+// NewRepeatPolar creates a shape that repeats another shape count times around cx, cy.
+// The shape should be positioned along the positive x axis from cx, cy.
+func NewRepeatPolar(shape Shape, cx, cy float64, count int) Shape {
+	return &repeatPolarShape{shape, cx, cy, count}
+}
+
+func (s *repeatPolarShape) Distance(x, y float64) float64 {
+	x, y = RepeatPolarFrom(x, y, s.cx, s.cy, s.count)
+	return s.shape.Distance(x, y)
+}
+
+func (s *repeatPolarShape) Gradient(x, y float64) (float64, float64) {
+	px, py := RepeatPolarFrom(x, y, s.cx, s.cy, s.count)
+	gx, gy := s.shape.Gradient(px, py)
+	// rotate the gradient back by the angle between the original and repeated points.
+	angle := math.Atan2(py-s.cy, px-s.cx) - math.Atan2(y-s.cy, x-s.cx)
+	return Rotate(gx, gy, angle)
+}
+
+func (s *repeatPolarShape) Bounds() *geom.Rect {
+	b := s.shape.Bounds()
+	if b == nil {
+		return nil
+	}
+	radius := 0.0
+	for _, c := range [][2]float64{{b.X, b.Y}, {b.X + b.W, b.Y}, {b.X + b.W, b.Y + b.H}, {b.X, b.Y + b.H}} {
+		radius = math.Max(radius, math.Hypot(c[0]-s.cx, c[1]-s.cy))
+	}
+	return circleBounds(s.cx, s.cy, radius)
+}
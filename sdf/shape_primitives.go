@@ -0,0 +1,393 @@
+// Package sdf defines signed distance functions
+package sdf
+
+import (
+	"math"
+
+	"github.com/bit101/bitlib/geom"
+)
+
+//////////////////////////////
+// Circle
+//////////////////////////////
+
+type circleShape struct {
+	cx, cy, radius float64
+}
+
+// NewCircle creates a circle shape.
+func NewCircle(cx, cy, radius float64) Shape {
+	return &circleShape{cx, cy, radius}
+}
+
+func (s *circleShape) Distance(x, y float64) float64 {
+	return Circle(x, y, s.cx, s.cy, s.radius)
+}
+
+func (s *circleShape) Gradient(x, y float64) (float64, float64) {
+	dx := x - s.cx
+	dy := y - s.cy
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return 0, 0
+	}
+	return dx / l, dy / l
+}
+
+func (s *circleShape) Bounds() *geom.Rect {
+	return circleBounds(s.cx, s.cy, s.radius)
+}
+
+//////////////////////////////
+// Box
+//////////////////////////////
+
+type boxShape struct {
+	bx, by, bw, bh, r float64
+}
+
+// NewBox creates an axis-aligned box shape. bw and bh are half the width and height.
+func NewBox(bx, by, bw, bh float64) Shape {
+	return &boxShape{bx, by, bw, bh, 0}
+}
+
+// NewRoundBox creates an axis-aligned rounded box shape. bw and bh are half the width and height.
+func NewRoundBox(bx, by, bw, bh, r float64) Shape {
+	return &boxShape{bx, by, bw, bh, r}
+}
+
+func (s *boxShape) Distance(x, y float64) float64 {
+	if s.r == 0 {
+		return Box(x, y, s.bx, s.by, s.bw, s.bh)
+	}
+	return RoundBox(x, y, s.bx, s.by, s.bw, s.bh, s.r)
+}
+
+func (s *boxShape) Gradient(x, y float64) (float64, float64) {
+	// a rounded box is a smaller box grown by r, which has the same gradient.
+	px := x - s.bx
+	py := y - s.by
+	wx := math.Abs(px) - (s.bw - s.r)
+	wy := math.Abs(py) - (s.bh - s.r)
+	sx := 1.0
+	if px < 0 {
+		sx = -1
+	}
+	sy := 1.0
+	if py < 0 {
+		sy = -1
+	}
+	if math.Max(wx, wy) > 0 {
+		qx := math.Max(wx, 0)
+		qy := math.Max(wy, 0)
+		l := math.Hypot(qx, qy)
+		return sx * qx / l, sy * qy / l
+	}
+	if wx > wy {
+		return sx, 0
+	}
+	return 0, sy
+}
+
+func (s *boxShape) Bounds() *geom.Rect {
+	return geom.NewRect(s.bx-s.bw, s.by-s.bh, s.bw*2, s.bh*2)
+}
+
+//////////////////////////////
+// Segment
+//////////////////////////////
+
+type segmentShape struct {
+	ax, ay, bx, by float64
+}
+
+// NewSegment creates a line segment shape. The distance is unsigned, so use NewRound to give it a thickness.
+func NewSegment(ax, ay, bx, by float64) Shape {
+	return &segmentShape{ax, ay, bx, by}
+}
+
+func (s *segmentShape) Distance(x, y float64) float64 {
+	return Segment(x, y, s.ax, s.ay, s.bx, s.by)
+}
+
+func (s *segmentShape) Gradient(x, y float64) (float64, float64) {
+	seg := geom.NewSegment(s.ax, s.ay, s.bx, s.by)
+	c := seg.ClosestPoint(geom.NewPoint(x, y))
+	dx := x - c.X
+	dy := y - c.Y
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return 0, 0
+	}
+	return dx / l, dy / l
+}
+
+func (s *segmentShape) Bounds() *geom.Rect {
+	return pointBounds(s.ax, s.ay, s.bx, s.by)
+}
+
+//////////////////////////////
+// Oriented box
+//////////////////////////////
+
+type orientedBoxShape struct {
+	ax, ay, bx, by, th float64
+}
+
+// NewOrientedBox creates a box shape running from point a to point b, with the given thickness.
+func NewOrientedBox(ax, ay, bx, by, th float64) Shape {
+	return &orientedBoxShape{ax, ay, bx, by, th}
+}
+
+func (s *orientedBoxShape) Distance(x, y float64) float64 {
+	return OrientedBox(x, y, s.ax, s.ay, s.bx, s.by, s.th)
+}
+
+func (s *orientedBoxShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *orientedBoxShape) Bounds() *geom.Rect {
+	l := math.Hypot(s.bx-s.ax, s.by-s.ay)
+	nx := -(s.by - s.ay) / l * s.th / 2
+	ny := (s.bx - s.ax) / l * s.th / 2
+	return pointBounds(
+		s.ax+nx, s.ay+ny, s.bx+nx, s.by+ny,
+		s.ax-nx, s.ay-ny, s.bx-nx, s.by-ny,
+	)
+}
+
+//////////////////////////////
+// Ellipse
+//////////////////////////////
+
+type ellipseShape struct {
+	cx, cy, rx, ry float64
+}
+
+// NewEllipse creates an axis-aligned ellipse shape.
+func NewEllipse(cx, cy, rx, ry float64) Shape {
+	return &ellipseShape{cx, cy, rx, ry}
+}
+
+func (s *ellipseShape) Distance(x, y float64) float64 {
+	return Ellipse(x, y, s.cx, s.cy, s.rx, s.ry)
+}
+
+func (s *ellipseShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *ellipseShape) Bounds() *geom.Rect {
+	return geom.NewRect(s.cx-s.rx, s.cy-s.ry, s.rx*2, s.ry*2)
+}
+
+//////////////////////////////
+// Polygons
+//////////////////////////////
+
+type polygonShape struct {
+	xs, ys []float64
+}
+
+// NewTriangle creates a triangle shape.
+func NewTriangle(x0, y0, x1, y1, x2, y2 float64) Shape {
+	return &polygonShape{[]float64{x0, x1, x2}, []float64{y0, y1, y2}}
+}
+
+// NewPolygon creates a polygon shape from a list of points.
+func NewPolygon(points geom.PointList) Shape {
+	s := &polygonShape{}
+	for _, p := range points {
+		s.xs = append(s.xs, p.X)
+		s.ys = append(s.ys, p.Y)
+	}
+	return s
+}
+
+func (s *polygonShape) Distance(x, y float64) float64 {
+	return polygon(x, y, s.xs, s.ys)
+}
+
+func (s *polygonShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *polygonShape) Bounds() *geom.Rect {
+	coords := []float64{}
+	for i := range s.xs {
+		coords = append(coords, s.xs[i], s.ys[i])
+	}
+	return pointBounds(coords...)
+}
+
+type starShape struct {
+	cx, cy, radius float64
+	points         int
+	m, rotation    float64
+}
+
+// NewRegularPolygon creates a regular polygon shape.
+func NewRegularPolygon(cx, cy, radius float64, sides int, rotation float64) Shape {
+	return &starShape{cx, cy, radius, sides, 2, rotation}
+}
+
+// NewStar creates a star shape. See Star for the meaning of the params.
+func NewStar(cx, cy, radius float64, points int, m, rotation float64) Shape {
+	return &starShape{cx, cy, radius, points, m, rotation}
+}
+
+func (s *starShape) Distance(x, y float64) float64 {
+	return Star(x, y, s.cx, s.cy, s.radius, s.points, s.m, s.rotation)
+}
+
+func (s *starShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *starShape) Bounds() *geom.Rect {
+	return circleBounds(s.cx, s.cy, s.radius)
+}
+
+//////////////////////////////
+// Arcs
+//////////////////////////////
+
+type arcShape struct {
+	cx, cy, radius, start, end, width float64
+}
+
+// NewArc creates a circular arc shape from the start angle to the end angle, with the given width.
+func NewArc(cx, cy, radius, start, end, width float64) Shape {
+	return &arcShape{cx, cy, radius, start, end, width}
+}
+
+func (s *arcShape) Distance(x, y float64) float64 {
+	return Arc(x, y, s.cx, s.cy, s.radius, s.start, s.end, s.width)
+}
+
+func (s *arcShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *arcShape) Bounds() *geom.Rect {
+	return circleBounds(s.cx, s.cy, s.radius+s.width/2)
+}
+
+type pieShape struct {
+	cx, cy, radius, start, end float64
+}
+
+// NewPie creates a pie wedge shape from the start angle to the end angle.
+func NewPie(cx, cy, radius, start, end float64) Shape {
+	return &pieShape{cx, cy, radius, start, end}
+}
+
+func (s *pieShape) Distance(x, y float64) float64 {
+	return Pie(x, y, s.cx, s.cy, s.radius, s.start, s.end)
+}
+
+func (s *pieShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *pieShape) Bounds() *geom.Rect {
+	return circleBounds(s.cx, s.cy, s.radius)
+}
+
+//////////////////////////////
+// Curves
+//////////////////////////////
+
+type quadraticBezierShape struct {
+	ax, ay, bx, by, cx, cy float64
+}
+
+// NewQuadraticBezier creates a quadratic Bezier curve shape.
+// The distance is unsigned, so use NewRound to give it a thickness.
+func NewQuadraticBezier(ax, ay, bx, by, cx, cy float64) Shape {
+	return &quadraticBezierShape{ax, ay, bx, by, cx, cy}
+}
+
+func (s *quadraticBezierShape) Distance(x, y float64) float64 {
+	return QuadraticBezier(x, y, s.ax, s.ay, s.bx, s.by, s.cx, s.cy)
+}
+
+func (s *quadraticBezierShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *quadraticBezierShape) Bounds() *geom.Rect {
+	// the curve is always inside the hull of its control points.
+	return pointBounds(s.ax, s.ay, s.bx, s.by, s.cx, s.cy)
+}
+
+//////////////////////////////
+// Misc shapes
+//////////////////////////////
+
+type vesicaShape struct {
+	cx, cy, r, d float64
+}
+
+// NewVesica creates a vertical vesica (lens) shape.
+func NewVesica(cx, cy, r, d float64) Shape {
+	return &vesicaShape{cx, cy, r, d}
+}
+
+func (s *vesicaShape) Distance(x, y float64) float64 {
+	return Vesica(x, y, s.cx, s.cy, s.r, s.d)
+}
+
+func (s *vesicaShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *vesicaShape) Bounds() *geom.Rect {
+	w := s.r - s.d
+	h := math.Sqrt(s.r*s.r - s.d*s.d)
+	return geom.NewRect(s.cx-w, s.cy-h, w*2, h*2)
+}
+
+type crossShape struct {
+	cx, cy, length, thickness, r float64
+}
+
+// NewCross creates a plus shaped cross shape.
+func NewCross(cx, cy, length, thickness, r float64) Shape {
+	return &crossShape{cx, cy, length, thickness, r}
+}
+
+func (s *crossShape) Distance(x, y float64) float64 {
+	return Cross(x, y, s.cx, s.cy, s.length, s.thickness, s.r)
+}
+
+func (s *crossShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *crossShape) Bounds() *geom.Rect {
+	size := s.length - math.Min(s.r, 0)
+	return circleBounds(s.cx, s.cy, size)
+}
+
+type roundedXShape struct {
+	cx, cy, width, r float64
+}
+
+// NewRoundedX creates an X shape with rounded arms.
+func NewRoundedX(cx, cy, width, r float64) Shape {
+	return &roundedXShape{cx, cy, width, r}
+}
+
+func (s *roundedXShape) Distance(x, y float64) float64 {
+	return RoundedX(x, y, s.cx, s.cy, s.width, s.r)
+}
+
+func (s *roundedXShape) Gradient(x, y float64) (float64, float64) {
+	return NumericGradient(s.Distance, x, y)
+}
+
+func (s *roundedXShape) Bounds() *geom.Rect {
+	return circleBounds(s.cx, s.cy, s.width/2+s.r)
+}
@@ -0,0 +1,132 @@
+// Package sdf defines signed distance functions
+package sdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bit101/bitlib/blmath"
+	"github.com/bit101/bitlib/geom"
+)
+
+// testShapes returns a mix of primitives, operators and transforms.
+func testShapes() map[string]Shape {
+	return map[string]Shape{
+		"circle":          NewCircle(50, 50, 20),
+		"box":             NewBox(50, 50, 30, 10),
+		"round box":       NewRoundBox(50, 50, 30, 10, 5),
+		"segment":         NewSegment(20, 30, 80, 70),
+		"oriented box":    NewOrientedBox(20, 30, 80, 70, 10),
+		"ellipse":         NewEllipse(50, 50, 30, 15),
+		"triangle":        NewTriangle(20, 80, 50, 20, 80, 80),
+		"star":            NewStar(50, 50, 30, 5, 3, 0),
+		"arc":             NewArc(50, 50, 25, 0, math.Pi, 4),
+		"pie":             NewPie(50, 50, 25, 0, math.Pi/2),
+		"union":           NewUnion(NewCircle(40, 50, 15), NewBox(65, 50, 10, 20)),
+		"intersection":    NewIntersection(NewCircle(50, 50, 25), NewBox(60, 50, 20, 20)),
+		"subtraction":     NewSubtraction(NewCircle(50, 50, 25), NewCircle(60, 50, 10)),
+		"smooth union":    NewSmoothUnion(NewCircle(40, 50, 15), NewCircle(65, 50, 15), 5),
+		"annular":         NewAnnular(NewBox(50, 50, 20, 20), 3),
+		"translate":       NewTranslate(NewCircle(0, 0, 20), 50, 50),
+		"rotate":          NewRotate(NewBox(50, 50, 30, 10), 50, 50, 0.5),
+		"scale":           NewScale(NewEllipse(50, 50, 20, 10), 50, 50, 1.5),
+		"repeat polar":    NewRepeatPolar(NewCircle(75, 50, 5), 50, 50, 6),
+		"mirror":          NewMirror(NewCircle(30, 40, 10), 50, 50, 0),
+		"rounded x":       NewRoundedX(50, 50, 40, 4),
+		"vesica":          NewVesica(50, 50, 30, 15),
+		"quadratic":       NewQuadraticBezier(20, 80, 50, 0, 80, 80),
+		"regular poly":    NewRegularPolygon(50, 50, 30, 6, 0),
+		"cross":           NewCross(50, 50, 30, 8, 0),
+		"onion":           NewOnion(NewCircle(50, 50, 20), 2, 2),
+		"smooth subtract": NewSmoothSubtraction(NewCircle(50, 50, 25), NewCircle(65, 50, 12), 4),
+	}
+}
+
+func TestShapeDistance(t *testing.T) {
+	type test struct {
+		name     string
+		shape    Shape
+		x, y     float64
+		expected float64
+	}
+	tests := []test{
+		{"circle center", NewCircle(50, 50, 20), 50, 50, -20},
+		{"circle edge", NewCircle(50, 50, 20), 70, 50, 0},
+		{"circle outside", NewCircle(50, 50, 20), 50, 80, 10},
+		{"box inside", NewBox(50, 50, 30, 10), 50, 50, -10},
+		{"box side", NewBox(50, 50, 30, 10), 90, 50, 10},
+		{"box corner", NewBox(50, 50, 30, 10), 83, 64, 5},
+		{"round box corner", NewRoundBox(50, 50, 30, 10, 5), 80, 60, 5*math.Sqrt2 - 5},
+		{"segment end", NewSegment(0, 0, 10, 0), -3, 4, 5},
+		{"segment middle", NewSegment(0, 0, 10, 0), 5, -2, 2},
+		{"ellipse vertex", NewEllipse(50, 50, 30, 15), 90, 50, 10},
+		{"triangle outside", NewTriangle(0, 0, 10, 0, 0, 10), 20, 0, 10},
+		{"union", NewUnion(NewCircle(0, 0, 5), NewCircle(20, 0, 5)), 12, 0, 3},
+		{"intersection", NewIntersection(NewCircle(0, 0, 10), NewCircle(10, 0, 10)), 5, 0, -5},
+		{"subtraction", NewSubtraction(NewCircle(0, 0, 10), NewCircle(0, 0, 5)), 0, 0, 5},
+		{"translate", NewTranslate(NewCircle(0, 0, 5), 10, 10), 10, 20, 5},
+		{"scale", NewScale(NewCircle(0, 0, 5), 0, 0, 2), 20, 0, 10},
+		{"annular", NewAnnular(NewCircle(0, 0, 10), 2), 0, 0, 8},
+	}
+	for _, test := range tests {
+		result := test.shape.Distance(test.x, test.y)
+		if !blmath.Equalish(result, test.expected, 1e-9) {
+			t.Errorf("%s: Expected %f, got %f\n", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestShapeGradient(t *testing.T) {
+	for name, shape := range testShapes() {
+		for y := 3.7; y < 100; y += 7.3 {
+			for x := 2.9; x < 100; x += 7.1 {
+				gx, gy := shape.Gradient(x, y)
+				nx, ny := NumericGradient(shape.Distance, x, y)
+				// skip the creases where the field isn't smooth, like medial axes and corners.
+				if math.Abs(math.Hypot(nx, ny)-1) > 1e-3 {
+					continue
+				}
+				if !blmath.Equalish(gx, nx, 1e-3) || !blmath.Equalish(gy, ny, 1e-3) {
+					t.Errorf("%s at %f, %f: Expected %f, %f, got %f, %f\n", name, x, y, nx, ny, gx, gy)
+				}
+			}
+		}
+	}
+}
+
+func TestShapeBounds(t *testing.T) {
+	for name, shape := range testShapes() {
+		bounds := shape.Bounds()
+		if bounds == nil {
+			continue
+		}
+		// every point on or inside the shape should be in the bounds.
+		for y := -20.0; y <= 120; y += 0.5 {
+			for x := -20.0; x <= 120; x += 0.5 {
+				if shape.Distance(x, y) > 0 {
+					continue
+				}
+				if BoundsDistance(bounds, x, y) > 1e-9 {
+					t.Errorf("%s: point %f, %f is inside the shape but outside bounds %v\n", name, x, y, *bounds)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestBoundsDistance(t *testing.T) {
+	bounds := geom.NewRect(0, 0, 10, 10)
+	result := BoundsDistance(bounds, 13, 14)
+	if result != 5 {
+		t.Errorf("Expected %f, got %f\n", 5.0, result)
+	}
+	result = BoundsDistance(bounds, 5, 5)
+	if result != 0 {
+		t.Errorf("Expected %f, got %f\n", 0.0, result)
+	}
+	result = BoundsDistance(nil, 50, 50)
+	if result != 0 {
+		t.Errorf("Expected %f, got %f\n", 0.0, result)
+	}
+}
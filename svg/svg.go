@@ -0,0 +1,274 @@
+// Package svg writes geometry to SVG files.
+package svg
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/bitlib/blmath"
+	"github.com/bit101/bitlib/geom"
+)
+
+// Unit is the unit that a document's width and height are measured in.
+type Unit int
+
+const (
+	// Pixels are CSS pixels, 96 to the inch.
+	Pixels Unit = iota
+	// Millimeters are used for most plotter paper sizes.
+	Millimeters
+	// Inches are 25.4 millimeters.
+	Inches
+)
+
+// suffix returns the suffix used for the unit in the svg file.
+func (u Unit) suffix() string {
+	switch u {
+	case Millimeters:
+		return "mm"
+	case Inches:
+		return "in"
+	}
+	return "px"
+}
+
+// Style holds the stroke and fill of an element.
+// A color with an alpha of 0 is not drawn, so the zero Style draws nothing.
+type Style struct {
+	Stroke    blcolor.Color
+	Fill      blcolor.Color
+	LineWidth float64
+}
+
+// StrokeStyle creates a Style that strokes with the given color and line width, with no fill.
+func StrokeStyle(color blcolor.Color, lineWidth float64) Style {
+	return Style{Stroke: color, LineWidth: lineWidth}
+}
+
+// FillStyle creates a Style that fills with the given color, with no stroke.
+func FillStyle(color blcolor.Color) Style {
+	return Style{Fill: color}
+}
+
+// attributes returns the style as svg attributes.
+func (s Style) attributes() string {
+	attrs := []string{}
+	if s.Stroke.A > 0 {
+		attrs = append(attrs, fmt.Sprintf(`stroke="%s"`, hex(s.Stroke)))
+		if s.Stroke.A < 1 {
+			attrs = append(attrs, fmt.Sprintf(`stroke-opacity="%s"`, format(s.Stroke.A)))
+		}
+		attrs = append(attrs, fmt.Sprintf(`stroke-width="%s"`, format(s.LineWidth)))
+	} else {
+		attrs = append(attrs, `stroke="none"`)
+	}
+	if s.Fill.A > 0 {
+		attrs = append(attrs, fmt.Sprintf(`fill="%s"`, hex(s.Fill)))
+		if s.Fill.A < 1 {
+			attrs = append(attrs, fmt.Sprintf(`fill-opacity="%s"`, format(s.Fill.A)))
+		}
+	} else {
+		attrs = append(attrs, `fill="none"`)
+	}
+	return strings.Join(attrs, " ")
+}
+
+//////////////////////////////
+// Document
+//////////////////////////////
+
+// Document is an svg document. Elements added directly to it go in the top level group.
+type Document struct {
+	*Group
+	// Width and Height are the size of the document, measured in Unit.
+	Width, Height float64
+	Unit          Unit
+	// ViewBox is the area of user space that fills the document.
+	// It starts as 0, 0, Width, Height, so user space is measured in Unit.
+	ViewBox *geom.Rect
+}
+
+// NewDocument creates a new Document of the given size.
+func NewDocument(width, height float64, unit Unit) *Document {
+	return &Document{
+		Group:   &Group{},
+		Width:   width,
+		Height:  height,
+		Unit:    unit,
+		ViewBox: geom.NewRect(0, 0, width, height),
+	}
+}
+
+// Encode writes the document to a writer.
+func (d *Document) Encode(w io.Writer) error {
+	buff := bufio.NewWriter(w)
+	fmt.Fprintln(buff, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintf(buff, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape" `)
+	fmt.Fprintf(buff, `width="%s%s" height="%s%s" `, format(d.Width), d.Unit.suffix(), format(d.Height), d.Unit.suffix())
+	v := d.ViewBox
+	fmt.Fprintf(buff, `viewBox="%s %s %s %s">`+"\n", format(v.X), format(v.Y), format(v.W), format(v.H))
+	d.Group.writeChildren(buff, "  ")
+	fmt.Fprintln(buff, "</svg>")
+	return buff.Flush()
+}
+
+// String returns the document as svg.
+func (d *Document) String() string {
+	var b strings.Builder
+	d.Encode(&b)
+	return b.String()
+}
+
+// Save saves the document to a file.
+func (d *Document) Save(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := d.Encode(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+//////////////////////////////
+// Group
+//////////////////////////////
+
+// Group is a group of elements, which can include other groups.
+type Group struct {
+	// ID is the id of the group. It is left out if empty.
+	ID string
+	// Layer marks the group as a layer for Inkscape and plotter software, using ID as its name.
+	Layer bool
+	// items holds rendered elements and child groups, in order.
+	items []any
+}
+
+// AddGroup adds a new group inside this one and returns it.
+func (g *Group) AddGroup(id string) *Group {
+	group := &Group{ID: id}
+	g.items = append(g.items, group)
+	return group
+}
+
+// AddLayer adds a new layer inside this one and returns it.
+func (g *Group) AddLayer(name string) *Group {
+	group := g.AddGroup(name)
+	group.Layer = true
+	return group
+}
+
+// AddPolyline adds a list of points as an open polyline.
+func (g *Group) AddPolyline(points geom.PointList, style Style) {
+	g.add(fmt.Sprintf(`<polyline points="%s" %s/>`, pointsAttr(points), style.attributes()))
+}
+
+// AddPolygon adds a list of points as a closed polygon.
+func (g *Group) AddPolygon(points geom.PointList, style Style) {
+	g.add(fmt.Sprintf(`<polygon points="%s" %s/>`, pointsAttr(points), style.attributes()))
+}
+
+// AddSegments adds each segment in a list as a line.
+func (g *Group) AddSegments(segments geom.SegmentList, style Style) {
+	for _, s := range segments {
+		g.add(fmt.Sprintf(`<line x1="%s" y1="%s" x2="%s" y2="%s" %s/>`,
+			format(s.PointA.X), format(s.PointA.Y), format(s.PointB.X), format(s.PointB.Y), style.attributes()))
+	}
+}
+
+// AddCircles adds each circle in a list.
+func (g *Group) AddCircles(circles geom.CircleList, style Style) {
+	for _, c := range circles {
+		g.add(fmt.Sprintf(`<circle cx="%s" cy="%s" r="%s" %s/>`,
+			format(c.X), format(c.Y), format(c.Radius), style.attributes()))
+	}
+}
+
+// AddTriangles adds each triangle in a list as a polygon.
+func (g *Group) AddTriangles(triangles geom.TriangleList, style Style) {
+	for _, t := range triangles {
+		g.AddPolygon(t.Points(), style)
+	}
+}
+
+// AddBezier adds a cubic bezier curve as a path.
+func (g *Group) AddBezier(curve *geom.BezierCurve, style Style) {
+	g.add(fmt.Sprintf(`<path d="M %s C %s %s %s" %s/>`,
+		pointAttr(curve.P0), pointAttr(curve.P1), pointAttr(curve.P2), pointAttr(curve.P3), style.attributes()))
+}
+
+// add adds a rendered element.
+func (g *Group) add(element string) {
+	g.items = append(g.items, element)
+}
+
+// write writes the group and its children.
+func (g *Group) write(w io.Writer, indent string) {
+	attrs := ""
+	if g.ID != "" {
+		attrs += fmt.Sprintf(` id="%s"`, html.EscapeString(g.ID))
+	}
+	if g.Layer {
+		attrs += fmt.Sprintf(` inkscape:groupmode="layer" inkscape:label="%s"`, html.EscapeString(g.ID))
+	}
+	fmt.Fprintf(w, "%s<g%s>\n", indent, attrs)
+	g.writeChildren(w, indent+"  ")
+	fmt.Fprintf(w, "%s</g>\n", indent)
+}
+
+// writeChildren writes the group's elements and child groups.
+func (g *Group) writeChildren(w io.Writer, indent string) {
+	for _, item := range g.items {
+		switch item := item.(type) {
+		case string:
+			fmt.Fprintf(w, "%s%s\n", indent, item)
+		case *Group:
+			item.write(w, indent)
+		}
+	}
+}
+
+//////////////////////////////
+// Formatting
+//////////////////////////////
+
+// format formats a number to at most three decimal places, without trailing zeros.
+func format(value float64) string {
+	value = math.Round(value*1000) / 1000
+	if value == 0 {
+		// avoids "-0".
+		value = 0
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// pointAttr formats a point as x,y.
+func pointAttr(p *geom.Point) string {
+	return format(p.X) + "," + format(p.Y)
+}
+
+// pointsAttr formats a list of points as x,y pairs separated by spaces.
+func pointsAttr(points geom.PointList) string {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = pointAttr(p)
+	}
+	return strings.Join(coords, " ")
+}
+
+// hex formats a color as #rrggbb.
+func hex(c blcolor.Color) string {
+	channel := func(v float64) int {
+		return int(math.Round(blmath.Clamp(v, 0, 1) * 255))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", channel(c.R), channel(c.G), channel(c.B))
+}
@@ -0,0 +1,97 @@
+// Package svg writes geometry to SVG files.
+package svg
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bit101/bitlib/blcolor"
+	"github.com/bit101/bitlib/geom"
+)
+
+func TestDocument(t *testing.T) {
+	type test struct {
+		unit     Unit
+		expected string
+	}
+	tests := []test{
+		{Pixels, `width="800px" height="600px" viewBox="0 0 800 600"`},
+		{Millimeters, `width="800mm" height="600mm" viewBox="0 0 800 600"`},
+		{Inches, `width="800in" height="600in" viewBox="0 0 800 600"`},
+	}
+	for _, test := range tests {
+		result := NewDocument(800, 600, test.unit).String()
+		if !strings.Contains(result, test.expected) {
+			t.Errorf("Expected %q in\n%s\n", test.expected, result)
+		}
+	}
+
+	doc := NewDocument(210, 297, Millimeters)
+	doc.ViewBox = geom.NewRect(0, 0, 2100, 2970.5)
+	result := doc.String()
+	if !strings.Contains(result, `viewBox="0 0 2100 2970.5"`) {
+		t.Errorf("Expected viewBox in\n%s\n", result)
+	}
+}
+
+func TestElements(t *testing.T) {
+	doc := NewDocument(100, 100, Pixels)
+	style := StrokeStyle(blcolor.RGB(1, 0, 0), 0.5)
+	points := geom.NewPointList()
+	points.AddXY(0, 0)
+	points.AddXY(10.12345, 20)
+	points.AddXY(-0.0001, 5)
+	doc.AddPolyline(points, style)
+	doc.AddPolygon(points, FillStyle(blcolor.RGBA(0, 0, 1, 0.5)))
+	segments := geom.NewSegmentList()
+	segments.Add(geom.NewSegment(1, 2, 3, 4))
+	doc.AddSegments(segments, style)
+	circles := geom.NewCircleList()
+	circles.AddXY(50, 50, 25)
+	doc.AddCircles(circles, Style{})
+	triangles := geom.NewTriangleList()
+	triangles.Add(geom.NewTriangle(0, 0, 10, 0, 0, 10))
+	doc.AddTriangles(triangles, style)
+	doc.AddBezier(geom.NewBezierCurve(geom.NewPoint(0, 0), geom.NewPoint(10, 0), geom.NewPoint(10, 10), geom.NewPoint(0, 10)), style)
+
+	result := doc.String()
+	expected := []string{
+		`<polyline points="0,0 10.123,20 0,5" stroke="#ff0000" stroke-width="0.5" fill="none"/>`,
+		`<polygon points="0,0 10.123,20 0,5" stroke="none" fill="#0000ff" fill-opacity="0.5"/>`,
+		`<line x1="1" y1="2" x2="3" y2="4" stroke="#ff0000" stroke-width="0.5" fill="none"/>`,
+		`<circle cx="50" cy="50" r="25" stroke="none" fill="none"/>`,
+		`<polygon points="0,0 10,0 0,10" stroke="#ff0000" stroke-width="0.5" fill="none"/>`,
+		`<path d="M 0,0 C 10,0 10,10 0,10" stroke="#ff0000" stroke-width="0.5" fill="none"/>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(result, e) {
+			t.Errorf("Expected %q in\n%s\n", e, result)
+		}
+	}
+}
+
+func TestGroups(t *testing.T) {
+	doc := NewDocument(100, 100, Pixels)
+	style := StrokeStyle(blcolor.RGB(0, 0, 0), 1)
+	points := geom.NewPointList()
+	points.AddXY(1, 1)
+	points.AddXY(2, 2)
+
+	layer := doc.AddLayer("pen <1>")
+	group := layer.AddGroup("")
+	group.AddPolyline(points, style)
+	doc.AddPolyline(points, style)
+
+	expected := `  <g id="pen &lt;1&gt;" inkscape:groupmode="layer" inkscape:label="pen &lt;1&gt;">
+    <g>
+      <polyline points="1,1 2,2" stroke="#000000" stroke-width="1" fill="none"/>
+    </g>
+  </g>
+  <polyline points="1,1 2,2" stroke="#000000" stroke-width="1" fill="none"/>
+</svg>
+`
+	result := doc.String()
+	if !strings.HasSuffix(result, expected) {
+		t.Errorf("Expected\n%s\ngot\n%s\n", expected, result)
+	}
+}